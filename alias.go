@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+)
+
+// aliases maps alias names, in lower case, to the command they stand for
+var aliases = map[string][]string{}
+
+// defineAlias parses a command and stores it as an alias
+func defineAlias(name string, command string) error {
+	parts, err := shellwords.Parse(command)
+	if err != nil {
+		return err
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("alias %s has no command", name)
+	}
+	aliases[strings.ToLower(name)] = parts
+	return nil
+}
+
+// aliasCommand implements :alias, listing the aliases, or with arguments
+// :alias <name> <command>, defining one. :alias <name> with no command
+// removes it.
+func aliasCommand(args []string) {
+	if len(args) == 0 {
+		names := make([]string, 0, len(aliases))
+		for name := range aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s = %s\n", name, quoteCommand(aliases[name]))
+		}
+		return
+	}
+
+	if len(args) == 1 {
+		delete(aliases, strings.ToLower(args[0]))
+		return
+	}
+
+	aliases[strings.ToLower(args[0])] = args[1:]
+}
+
+// expandAlias replaces a command's name with its alias, and the alias's
+// name with its own alias in turn, keeping the command's arguments. An
+// alias which leads back to itself is an error rather than a loop.
+func expandAlias(parts []string) ([]string, error) {
+	seen := map[string]bool{}
+	for {
+		name := strings.ToLower(parts[0])
+		alias, ok := aliases[name]
+		if !ok {
+			return parts, nil
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("alias %s expands to itself", name)
+		}
+		seen[name] = true
+		parts = append(append([]string{}, alias...), parts[1:]...)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/mattn/go-shellwords"
+)
+
+// assertionMark separates a command from the reply --assert expects of it
+const assertionMark = "=>"
+
+// expectation is the reply an --assert line expects. kind is value, nil,
+// empty, error or type, and words are the values, error prefix or type name
+// that go with it.
+type expectation struct {
+	kind  string
+	words []string
+}
+
+// splitAssertion splits a line at the first => which isn't quoted, returning
+// the command and the expectation after it, and whether there was one
+func splitAssertion(line string) (string, string, bool) {
+	var escaped, singlequoted, doublequoted bool
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && !singlequoted:
+			escaped = true
+		case r == '\'' && !doublequoted:
+			singlequoted = !singlequoted
+		case r == '"' && !singlequoted:
+			doublequoted = !doublequoted
+		case !singlequoted && !doublequoted && strings.HasPrefix(line[i:], assertionMark):
+			return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+len(assertionMark):]), true
+		}
+	}
+	return line, "", false
+}
+
+// parseExpectation reads what follows =>. The words nil, empty, error and
+// type have their special meaning only when they aren't quoted.
+func parseExpectation(text string) (expectation, error) {
+	var words []string
+	var err error
+	if *cliquoting {
+		words, err = splitArgs(text)
+	} else {
+		words, err = shellwords.Parse(text)
+	}
+	if err != nil {
+		return expectation{}, err
+	}
+	if len(words) == 0 {
+		return expectation{}, fmt.Errorf("nothing expected after %s", assertionMark)
+	}
+
+	keyword := strings.Fields(text)[0]
+	switch keyword {
+	case "nil", "empty":
+		if len(words) > 1 {
+			return expectation{}, fmt.Errorf("%s takes nothing after it", keyword)
+		}
+		return expectation{kind: keyword}, nil
+	case "error":
+		return expectation{kind: keyword, words: words[1:]}, nil
+	case "type":
+		if len(words) != 2 {
+			return expectation{}, fmt.Errorf("type takes one of string, integer, array, nil or error")
+		}
+		return expectation{kind: keyword, words: words[1:]}, nil
+	}
+	return expectation{kind: "value", words: words}, nil
+}
+
+// check compares a reply with the expectation, describing the difference
+// when they don't match
+func (e expectation) check(reply interface{}) error {
+	switch e.kind {
+	case "nil":
+		if reply == nil {
+			return nil
+		}
+		return fmt.Errorf("expected nil, got %s", describeReply(reply))
+	case "empty":
+		if values, ok := reply.([]interface{}); ok && len(values) == 0 {
+			return nil
+		}
+		return fmt.Errorf("expected an empty array, got %s", describeReply(reply))
+	case "error":
+		rediserr, ok := reply.(redis.Error)
+		prefix := strings.Join(e.words, " ")
+		if ok && strings.HasPrefix(rediserr.Error(), prefix) {
+			return nil
+		}
+		if prefix == "" {
+			return fmt.Errorf("expected an error, got %s", describeReply(reply))
+		}
+		return fmt.Errorf("expected error %s, got %s", prefix, describeReply(reply))
+	case "type":
+		if replyType(reply) == e.words[0] {
+			return nil
+		}
+		return fmt.Errorf("expected type %s, got %s", e.words[0], describeReply(reply))
+	}
+
+	got, ok := replyWords(reply)
+	if !ok || strings.Join(got, "\x00") != strings.Join(e.words, "\x00") {
+		if _, array := reply.([]interface{}); !array && len(e.words) == 1 {
+			return fmt.Errorf("expected %s, got %s", quoteArg(e.words[0]), describeReply(reply))
+		}
+		return fmt.Errorf("expected %s, got %s", quoteCommand(e.words), describeReply(reply))
+	}
+	return nil
+}
+
+// replyType names the kind of a reply for => type
+func replyType(reply interface{}) string {
+	switch reply.(type) {
+	case nil:
+		return "nil"
+	case redis.Error:
+		return "error"
+	case int64:
+		return "integer"
+	case []interface{}:
+		return "array"
+	}
+	return "string"
+}
+
+// replyWords returns a reply, or the elements of an array reply, as text,
+// reporting false if it holds anything which isn't a string or integer
+func replyWords(reply interface{}) ([]string, bool) {
+	values, ok := reply.([]interface{})
+	if !ok {
+		values = []interface{}{reply}
+	}
+
+	words := make([]string, len(values))
+	for i, value := range values {
+		switch value := value.(type) {
+		case []byte:
+			words[i] = string(value)
+		case string:
+			words[i] = value
+		case int64:
+			words[i] = strconv.FormatInt(value, 10)
+		default:
+			return nil, false
+		}
+	}
+	return words, true
+}
+
+// describeReply shows a reply the way an expectation for it would be written
+func describeReply(reply interface{}) string {
+	switch reply := reply.(type) {
+	case nil:
+		return "nil"
+	case redis.Error:
+		return "error " + reply.Error()
+	case []interface{}:
+		if len(reply) == 0 {
+			return "empty"
+		}
+		if words, ok := replyWords(reply); ok {
+			return quoteCommand(words)
+		}
+		return "a nested array"
+	}
+	if words, ok := replyWords(reply); ok {
+		return quoteArg(words[0])
+	}
+	return fmt.Sprint(reply)
+}
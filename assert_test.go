@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestSplitAssertion(t *testing.T) {
+	tests := []struct {
+		line, command, expected string
+		found                   bool
+	}{
+		{`GET foo`, `GET foo`, ``, false},
+		{`GET foo => bar`, `GET foo`, `bar`, true},
+		{`GET foo =>`, `GET foo`, ``, true},
+		{`GET "a=>b" => nil`, `GET "a=>b"`, `nil`, true},
+		{`GET 'x => y'`, `GET 'x => y'`, ``, false},
+	}
+	for _, test := range tests {
+		command, expected, found := splitAssertion(test.line)
+		if command != test.command || expected != test.expected || found != test.found {
+			t.Errorf("splitAssertion(%q) = %q, %q, %v", test.line, command, expected, found)
+		}
+	}
+}
+
+func TestParseExpectation(t *testing.T) {
+	tests := []struct {
+		text string
+		want expectation
+	}{
+		{`bar`, expectation{"value", []string{"bar"}}},
+		{`"Ann Lee"`, expectation{"value", []string{"Ann Lee"}}},
+		{`a "b c"`, expectation{"value", []string{"a", "b c"}}},
+		{`nil`, expectation{kind: "nil"}},
+		{`"nil"`, expectation{"value", []string{"nil"}}},
+		{`empty`, expectation{kind: "empty"}},
+		{`error`, expectation{"error", []string{}}},
+		{`error WRONGTYPE`, expectation{"error", []string{"WRONGTYPE"}}},
+		{`type integer`, expectation{"type", []string{"integer"}}},
+	}
+	for _, test := range tests {
+		got, err := parseExpectation(test.text)
+		if err != nil {
+			t.Errorf("parseExpectation(%q) failed: %s", test.text, err)
+			continue
+		}
+		if got.kind != test.want.kind || len(got.words) != len(test.want.words) ||
+			(len(got.words) > 0 && !reflect.DeepEqual(got.words, test.want.words)) {
+			t.Errorf("parseExpectation(%q) = %+v, want %+v", test.text, got, test.want)
+		}
+	}
+
+	for _, text := range []string{``, `nil extra`, `type`, `type a b`, `"unbalanced`} {
+		if _, err := parseExpectation(text); err == nil {
+			t.Errorf("parseExpectation(%q) should fail", text)
+		}
+	}
+}
+
+func TestExpectationCheck(t *testing.T) {
+	tests := []struct {
+		expected string
+		reply    interface{}
+		pass     bool
+	}{
+		{`bar`, []byte("bar"), true},
+		{`bar`, []byte("baz"), false},
+		{`OK`, "OK", true},
+		{`3`, int64(3), true},
+		{`3`, int64(4), false},
+		{`a "b c"`, []interface{}{[]byte("a"), []byte("b c")}, true},
+		{`a`, []interface{}{[]byte("a"), []byte("b")}, false},
+		{`a`, []interface{}{[]byte("a")}, true},
+		{`nil`, nil, true},
+		{`nil`, []byte(""), false},
+		{`empty`, []interface{}{}, true},
+		{`empty`, nil, false},
+		{`error`, redis.Error("ERR nope"), true},
+		{`error`, []byte("fine"), false},
+		{`error WRONGTYPE`, redis.Error("WRONGTYPE Operation against a key"), true},
+		{`error WRONGTYPE`, redis.Error("ERR syntax error"), false},
+		{`type string`, []byte("x"), true},
+		{`type integer`, int64(1), true},
+		{`type array`, []interface{}{}, true},
+		{`type nil`, nil, true},
+		{`type error`, redis.Error("ERR"), true},
+		{`type string`, int64(1), false},
+	}
+	for _, test := range tests {
+		expect, err := parseExpectation(test.expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := expect.check(test.reply); (err == nil) != test.pass {
+			t.Errorf("%q against %#v: got %v, want pass %v", test.expected, test.reply, err, test.pass)
+		}
+	}
+}
+
+// runAssertions runs lines as an --assert commands file and returns its
+// exit status and output
+func runAssertions(t *testing.T, lines string, replies map[string]interface{}) (int, string) {
+	t.Helper()
+	setFlag(t, asserting, true)
+	useConn(t, replies)
+
+	path := filepath.Join(t.TempDir(), "commands.txt")
+	if err := ioutil.WriteFile(path, []byte(lines), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	status := 0
+	out := captureStdout(t, func() { status = runCommandsFile(path, false) })
+	return status, out
+}
+
+func TestAssertionsPass(t *testing.T) {
+	status, out := runAssertions(t, "GET foo => bar\nINCR foo => error ERR value\n", map[string]interface{}{
+		"GET foo":  []byte("bar"),
+		"INCR foo": redis.Error("ERR value is not an integer"),
+	})
+	if status != 0 || strings.Count(out, "PASS") != 2 {
+		t.Errorf("status %d, output:\n%s", status, out)
+	}
+}
+
+func TestAssertionsOnlyCompareServerErrors(t *testing.T) {
+	tests := []struct {
+		line    string
+		replies map[string]interface{}
+	}{
+		{"GET \"x => error\n", nil},
+		{"GET x => error\n", map[string]interface{}{"GET x": errors.New("connection reset")}},
+		{"GET @/no/such/file => error\n", nil},
+	}
+	for _, test := range tests {
+		status, out := runAssertions(t, test.line, test.replies)
+		if status != 1 || strings.Contains(out, "PASS") {
+			t.Errorf("%q: status %d, output:\n%s", test.line, status, out)
+		}
+	}
+}
+
+// captureStdout returns what fn prints to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		out, _ := ioutil.ReadAll(r)
+		done <- string(out)
+	}()
+	fn()
+	os.Stdout = saved
+	w.Close()
+	return <-done
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/peterh/liner"
+)
+
+// authAttempts is how many times redli asks for a password
+const authAttempts = 3
+
+// isAuthError reports whether err is the server asking for a password, or
+// refusing the one given
+func isAuthError(err error) bool {
+	rediserr, ok := err.(redis.Error)
+	if !ok {
+		return false
+	}
+	for _, prefix := range []string{"NOAUTH", "WRONGPASS", "ERR invalid password"} {
+		if strings.HasPrefix(rediserr.Error(), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate deals with the server refusing the session for want of the
+// right password. In a terminal it asks for the password and connects again
+// with it, otherwise it explains how to give one.
+func authenticate(err error) (redis.Conn, error) {
+	if !isTerminal(os.Stdin) {
+		return nil, authHint(err)
+	}
+
+	line := liner.NewLiner()
+	defer line.Close()
+
+	for attempt := 0; attempt < authAttempts; attempt++ {
+		fmt.Println(err)
+		password, perr := line.PasswordPrompt("Password: ")
+		if perr != nil {
+			return nil, authHint(err)
+		}
+
+		setPassword(password)
+		newconn, derr := dial()
+		if !isAuthError(derr) {
+			return newconn, derr
+		}
+		err = derr
+	}
+	return nil, authHint(err)
+}
+
+// authHint adds which flags give credentials to an authentication error
+func authHint(err error) error {
+	return fmt.Errorf("%s\nGive the password with --auth or REDIS_PASSWORD, and an ACL username with --user", err)
+}
+
+// setPassword makes later connections use password, with the ACL user if
+// there is one or else in the URL, as --auth would
+func setPassword(password string) {
+	if authuser != "" {
+		authpassword = password
+		return
+	}
+	if u, err := url.Parse(connectionurl); err == nil {
+		u.User = url.UserPassword("x", password)
+		connectionurl = u.String()
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// tlsProbeTimeout bounds the handshake made to see if a server speaks TLS
+const tlsProbeTimeout = 2 * time.Second
+
+// checkPlaintext PINGs a new plaintext connection. A server which only
+// speaks TLS answers with an alert or hangs up, which shows up as a
+// protocol error rather than a reply. When the server then completes a TLS
+// handshake, --auto-tls reconnects over TLS, verifying against the system's
+// root CAs as --tls would, and otherwise the error suggests --tls.
+func checkPlaintext(c redis.Conn, autotls bool) (redis.Conn, error) {
+	_, err := c.Do("PING")
+	if _, ok := err.(redis.Error); err == nil || ok {
+		return c, nil
+	}
+	c.Close()
+
+	u, perr := url.Parse(connectionurl)
+	if perr != nil || !speaksTLS(u.Host) {
+		return nil, err
+	}
+	if !autotls {
+		return nil, fmt.Errorf("%s\nThe server speaks TLS, try again with --tls or --auto-tls", err)
+	}
+
+	fmt.Fprintln(os.Stderr, "The server speaks TLS, reconnecting with TLS")
+	connectionurl = "rediss://" + strings.TrimPrefix(connectionurl, "redis://")
+	tlsconfig = &tls.Config{}
+	if *nohostverify {
+		skipHostnameVerification(tlsconfig)
+	}
+	dialoptions = append(dialoptions, redis.DialTLSConfig(tlsconfig))
+	return dial()
+}
+
+// speaksTLS reports whether a TLS handshake with addr succeeds. The
+// certificate isn't checked, as this only asks which protocol is spoken.
+func speaksTLS(addr string) bool {
+	dialer := *netdialer
+	dialer.Timeout = tlsProbeTimeout
+	netconn, err := tls.DialWithDialer(&dialer, "tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return false
+	}
+	netconn.Close()
+	return true
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// runCommandsFile runs the commands in a file, one to a line, printing each
+// reply, or collecting them into one JSON array with --format json. Blank
+// lines and lines starting with # are skipped. With --assert, lines may end
+// with => and the reply expected, and only whether those replies match is
+// printed. It prints a count of the commands which succeeded and failed on
+// stderr and returns the exit status, 1 if any failed.
+func runCommandsFile(path string, stoponerror bool) int {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	_, collect := formatter.(jsonFormatter)
+	collect = collect && !*asserting
+	replies := []interface{}{}
+
+	succeeded, failed := 0, 0
+	passed, mismatched := 0, 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 512*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var expected *expectation
+		if *asserting {
+			var text string
+			var found bool
+			line, text, found = splitAssertion(line)
+			if found {
+				expect, err := parseExpectation(text)
+				if err != nil {
+					failed++
+					fmt.Fprintf(os.Stderr, "Line %d failed: can't parse expected reply: %s\n", n, err)
+					if stoponerror {
+						fmt.Fprintf(os.Stderr, "Stopping at line %d\n", n)
+						break
+					}
+					continue
+				}
+				expected = &expect
+			}
+		}
+
+		commands, err := splitCommands(line)
+		if err != nil {
+			commands = [][]string{{line}}
+		}
+
+		stopped := false
+		for i, parts := range commands {
+			if err == nil && dryRunCommand(parts) {
+				continue
+			}
+			reply, ferr := runFileCommand(parts, err)
+			_, servererr := ferr.(redis.Error)
+			checking := expected != nil && i == len(commands)-1
+			if servererr && checking {
+				// The expectation decides whether an error reply is a failure
+				reply, ferr = ferr, nil
+			}
+			if ferr != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "Line %d failed: %s\n", n, ferr)
+				if _, ok := ferr.(redis.Error); ok {
+					reply = ferr
+				} else {
+					// Shown like a server error, with the generic code
+					reply = redis.Error("ERR " + ferr.Error())
+				}
+			} else {
+				succeeded++
+			}
+
+			var mismatch error
+			switch {
+			case *asserting:
+				if checking {
+					// Only replies from the server are compared, not
+					// commands which couldn't be parsed or sent
+					if ferr != nil {
+						mismatch = ferr
+					} else {
+						mismatch = expected.check(reply)
+					}
+					if mismatch != nil {
+						mismatched++
+						fmt.Printf("FAIL line %d: %s: %s\n", n, quoteCommand(parts), mismatch)
+					} else {
+						passed++
+						fmt.Printf("PASS line %d: %s\n", n, quoteCommand(parts))
+					}
+				}
+			case collect:
+				replies = append(replies, sortedReply(markDoubles(parts, reply)))
+			default:
+				printReply(parts, reply)
+			}
+
+			if (ferr != nil || mismatch != nil) && stoponerror {
+				fmt.Fprintf(os.Stderr, "Stopping at line %d\n", n)
+				stopped = true
+				break
+			}
+		}
+		if stopped {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	if collect {
+		printReply(nil, replies)
+	}
+
+	if !*dryrun {
+		fmt.Fprintf(os.Stderr, "%d commands succeeded, %d failed\n", succeeded, failed)
+		if *asserting {
+			fmt.Fprintf(os.Stderr, "%d assertions passed, %d failed\n", passed, mismatched)
+		}
+	}
+	if failed > 0 || mismatched > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runFileCommand runs one command from a --commands-file, unless the line
+// it is on couldn't be parsed
+func runFileCommand(parts []string, parseerr error) (interface{}, error) {
+	if parseerr != nil {
+		return nil, fmt.Errorf("can't parse command: %s", parseerr)
+	}
+	args, err := fileArgs(parts[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := doRetrying(*retrytrans, parts[0], args...)
+	logCommand(parts, reply, err)
+	return reply, err
+}
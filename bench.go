@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/mattn/go-shellwords"
+)
+
+// randPlaceholder is replaced in --bench arguments by a random number on
+// every request, as in redis-benchmark
+const randPlaceholder = "__rand_int__"
+
+// benchResult is what each --bench client reports when it finishes
+type benchResult struct {
+	latencies *latencyHistogram
+	errors    int
+	err       error
+}
+
+// benchmark runs command requests times, shared between clients which each
+// have their own connection, and reports the throughput and latencies.
+// Arguments containing __rand_int__ get a random number below randmax in
+// its place each time.
+func benchmark(command string, clients int, requests int, randmax int) {
+	parts, err := shellwords.Parse(command)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(parts) == 0 {
+		log.Fatal("No command to benchmark")
+	}
+	if clients < 1 {
+		clients = 1
+	}
+	if randmax < 1 {
+		log.Fatal("--rand-max must be at least 1")
+	}
+
+	fmt.Printf("Running %q %d times from %d clients\n", command, requests, clients)
+
+	results := make(chan benchResult, clients)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < clients; i++ {
+		share := requests / clients
+		if i < requests%clients {
+			share++
+		}
+		wg.Add(1)
+		go func(share int) {
+			defer wg.Done()
+			results <- benchClient(parts, share, randmax)
+		}(share)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	close(results)
+
+	total := &latencyHistogram{buckets: map[uint64]int64{}}
+	errors := 0
+	for result := range results {
+		if result.err != nil {
+			log.Fatal(result.err)
+		}
+		total.merge(result.latencies)
+		errors += result.errors
+	}
+
+	fmt.Printf("%d requests in %v, %.0f requests/sec\n", total.count, elapsed.Round(time.Millisecond), float64(total.count)/elapsed.Seconds())
+	if errors > 0 {
+		fmt.Printf("%d requests returned errors\n", errors)
+	}
+	if total.count == 0 {
+		return
+	}
+	fmt.Print(formatTable([]string{"min", "p50", "p95", "p99", "max"}, [][]string{{
+		total.min.String(),
+		total.percentile(50).String(),
+		total.percentile(95).String(),
+		total.percentile(99).String(),
+		total.max.String(),
+	}}))
+}
+
+// benchClient runs a command on its own connection, timing each request
+func benchClient(parts []string, requests int, randmax int) benchResult {
+	result := benchResult{latencies: &latencyHistogram{buckets: map[uint64]int64{}}}
+
+	c, err := dial()
+	if err != nil {
+		result.err = err
+		return result
+	}
+	defer c.Close()
+
+	args := interfaceArgs(parts[1:])
+	for i := 0; i < requests; i++ {
+		for j, part := range parts[1:] {
+			if strings.Contains(part, randPlaceholder) {
+				args[j] = strings.Replace(part, randPlaceholder, strconv.Itoa(rand.Intn(randmax)), -1)
+			}
+		}
+
+		start := time.Now()
+		_, err := c.Do(parts[0], args...)
+		result.latencies.record(time.Since(start))
+		if _, ok := err.(redis.Error); ok {
+			result.errors++
+		} else if err != nil {
+			result.err = err
+			return result
+		}
+	}
+	return result
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// netdialer makes the TCP connections which don't go through redigo's own
+// dialer, and all connections when --bind is given
+var netdialer = &net.Dialer{KeepAlive: 5 * time.Minute}
+
+// localAddr parses --bind, an IP address with an optional port, into the
+// address to connect from
+func localAddr(addr string) (*net.TCPAddr, error) {
+	host, port := addr, 0
+	if h, p, err := net.SplitHostPort(addr); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || n > 65535 {
+			return nil, fmt.Errorf("bad port %q in --bind", p)
+		}
+		host, port = h, n
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, fmt.Errorf("--bind needs an IP address, optionally with a port, not %q", addr)
+	}
+	return &net.TCPAddr{IP: ip, Port: port}, nil
+}
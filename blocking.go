@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// errInterrupted is returned when Ctrl-C abandons a running command
+var errInterrupted = errors.New("command interrupted")
+
+// blockingcommands are commands which may wait on the server indefinitely
+var blockingcommands = map[string]bool{
+	"wait":       true,
+	"waitaof":    true,
+	"blpop":      true,
+	"brpop":      true,
+	"blmove":     true,
+	"blmpop":     true,
+	"brpoplpush": true,
+	"bzpopmin":   true,
+	"bzpopmax":   true,
+	"bzmpop":     true,
+}
+
+// isBlockingCommand reports whether a command can block the connection,
+// either because it always may or because it is an XREAD or XREADGROUP
+// given a BLOCK option. Only the options before STREAMS are looked at, as
+// keys and IDs follow it.
+func isBlockingCommand(parts []string) bool {
+	name := strings.ToLower(parts[0])
+	if blockingcommands[name] {
+		return true
+	}
+	if name != "xread" && name != "xreadgroup" {
+		return false
+	}
+	for _, p := range parts[1:] {
+		switch strings.ToLower(p) {
+		case "block":
+			return true
+		case "streams":
+			return false
+		}
+	}
+	return false
+}
+
+type doResult struct {
+	reply interface{}
+	err   error
+}
+
+// doInterruptible runs a command while watching for Ctrl-C. If interrupted,
+// the connection is closed to abandon the command and a fresh one is dialled.
+func doInterruptible(cmd string, args ...interface{}) (interface{}, error) {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	running := conn
+	done := make(chan doResult, 1)
+	go func() {
+		reply, err := running.Do(cmd, args...)
+		done <- doResult{reply, err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.reply, result.err
+	case <-interrupt:
+		running.Close()
+		<-done
+
+		reconnect()
+		return nil, errInterrupted
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestIsBlockingCommand(t *testing.T) {
+	tests := []struct {
+		command  []string
+		blocking bool
+	}{
+		{[]string{"BLPOP", "list", "0"}, true},
+		{[]string{"wait", "1", "0"}, true},
+		{[]string{"XREAD", "BLOCK", "0", "STREAMS", "s", "$"}, true},
+		{[]string{"xreadgroup", "GROUP", "g", "c", "COUNT", "1", "block", "100", "STREAMS", "s", ">"}, true},
+		{[]string{"XREAD", "COUNT", "1", "STREAMS", "block", "0"}, false},
+		{[]string{"XREAD", "STREAMS", "s", "0"}, false},
+		{[]string{"SET", "block", "1"}, false},
+		{[]string{"GET", "block"}, false},
+		{[]string{"SADD", "s", "BLOCK"}, false},
+		{[]string{"LPOP", "list"}, false},
+	}
+	for _, test := range tests {
+		if got := isBlockingCommand(test.command); got != test.blocking {
+			t.Errorf("isBlockingCommand(%q) = %v, want %v", test.command, got, test.blocking)
+		}
+	}
+}
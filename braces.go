@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxBraceRange is the most arguments a single {a..b} range expands to
+const maxBraceRange = 100000
+
+// maxBraceWords is the most arguments braces may expand a command to, so
+// combined ranges such as {1..99999}{1..99999} are refused rather than
+// filling memory
+const maxBraceWords = 1000000
+
+var errBraceExpansion = errors.New("braces expand to more than 1000000 arguments")
+
+// braceRange matches the inside of a numeric {a..b} range
+var braceRange = regexp.MustCompile(`^(-?\d+)\.\.(-?\d+)$`)
+
+// rawWord is a word of a command as typed, before quotes are removed, with
+// whether each byte of it is quoted or escaped
+type rawWord struct {
+	text   string
+	quoted []bool
+}
+
+// expandBraces expands bash style braces in a command as typed, so that
+// key:{1..3} becomes key:1 key:2 key:3 and {a,b}:x becomes a:x b:x. Quoted
+// and escaped braces are left alone.
+func expandBraces(input string) (string, error) {
+	words := []string{}
+	for _, word := range rawWords(input) {
+		expanded, err := expandWord(word, maxBraceWords-len(words))
+		if err != nil {
+			return "", err
+		}
+		words = append(words, expanded...)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// rawWords splits a command at unquoted whitespace, keeping quotes in place
+func rawWords(input string) []rawWord {
+	words := []rawWord{}
+	var current rawWord
+	var escaped, singlequoted, doublequoted bool
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		quoted := true
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && !singlequoted:
+			escaped = true
+		case c == '\'' && !doublequoted:
+			singlequoted = !singlequoted
+		case c == '"' && !singlequoted:
+			doublequoted = !doublequoted
+		default:
+			quoted = singlequoted || doublequoted
+		}
+
+		if !quoted && (c == ' ' || c == '\t') {
+			if current.text != "" {
+				words = append(words, current)
+				current = rawWord{}
+			}
+			continue
+		}
+		current.text += string(c)
+		current.quoted = append(current.quoted, quoted)
+	}
+	if current.text != "" {
+		words = append(words, current)
+	}
+	return words
+}
+
+// expandWord expands the first unquoted brace expression in a word, then
+// any in the results, returning the word untouched if it has none. It fails
+// if that would make more than limit words.
+func expandWord(word rawWord, limit int) ([]string, error) {
+	for open := 0; open < len(word.text); open++ {
+		if word.text[open] != '{' || word.quoted[open] {
+			continue
+		}
+
+		close, commas := -1, []int{}
+		depth := 0
+		for i := open + 1; i < len(word.text) && close < 0; i++ {
+			if word.quoted[i] {
+				continue
+			}
+			switch word.text[i] {
+			case '{':
+				depth++
+			case '}':
+				if depth == 0 {
+					close = i
+				}
+				depth--
+			case ',':
+				if depth == 0 {
+					commas = append(commas, i)
+				}
+			}
+		}
+		if close < 0 {
+			break
+		}
+
+		items := braceItems(word, open, close, commas)
+		if items == nil {
+			continue
+		}
+
+		expanded := []string{}
+		for _, item := range items {
+			text := word.text[:open] + item.text + word.text[close+1:]
+			quoted := append(append(append([]bool{}, word.quoted[:open]...), item.quoted...), word.quoted[close+1:]...)
+			more, err := expandWord(rawWord{text, quoted}, limit-len(expanded))
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, more...)
+		}
+		return expanded, nil
+	}
+	if limit < 1 {
+		return nil, errBraceExpansion
+	}
+	return []string{word.text}, nil
+}
+
+// braceItems returns what the braces between open and close expand to, or
+// nil if they aren't a range or a list of at least two items
+func braceItems(word rawWord, open int, close int, commas []int) []rawWord {
+	if len(commas) > 0 {
+		items := []rawWord{}
+		start := open + 1
+		for _, end := range append(commas, close) {
+			items = append(items, rawWord{word.text[start:end], word.quoted[start:end]})
+			start = end + 1
+		}
+		return items
+	}
+
+	body := word.text[open+1 : close]
+	for _, quoted := range word.quoted[open+1 : close] {
+		if quoted {
+			return nil
+		}
+	}
+	match := braceRange.FindStringSubmatch(body)
+	if match == nil {
+		return nil
+	}
+	from, err1 := strconv.Atoi(match[1])
+	to, err2 := strconv.Atoi(match[2])
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+	step := 1
+	if to < from {
+		step = -1
+	}
+	if (to-from)*step >= maxBraceRange {
+		return nil
+	}
+
+	items := []rawWord{}
+	for n := from; ; n += step {
+		text := strconv.Itoa(n)
+		items = append(items, rawWord{text, make([]bool, len(text))})
+		if n == to {
+			break
+		}
+	}
+	return items
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"GET key", "GET key"},
+		{"DEL key:{1..3}", "DEL key:1 key:2 key:3"},
+		{"DEL key:{3..1}", "DEL key:3 key:2 key:1"},
+		{"SADD s {red,green}", "SADD s red green"},
+		{"MGET {a,b}:{1..2}", "MGET a:1 a:2 b:1 b:2"},
+		{`SET "{1..3}" x`, `SET "{1..3}" x`},
+		{`SET \{1..3} x`, `SET \{1..3} x`},
+		{"SET {1} x", "SET {1} x"},
+		{"SET {a..c} x", "SET {a..c} x"},
+	}
+	for _, test := range tests {
+		got, err := expandBraces(test.input)
+		if err != nil {
+			t.Errorf("expandBraces(%q): %s", test.input, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("expandBraces(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestExpandBracesLimit(t *testing.T) {
+	for _, input := range []string{
+		"DEL {1..99999}{1..99999}",
+		"DEL {1..1000}:{1..1000}",
+	} {
+		if _, err := expandBraces(input); err != errBraceExpansion {
+			t.Errorf("expandBraces(%q) error %v, want %v", input, err, errBraceExpansion)
+		}
+	}
+
+	if _, err := expandBraces("DEL key:{1..100000}"); err != nil {
+		t.Errorf("expandBraces of the largest range: %s", err)
+	}
+}
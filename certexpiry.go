@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"time"
+)
+
+// checkCertExpiry warns on stderr if the server's certificate expires
+// within the given number of days. It makes a TLS connection of its own, as
+// redigo doesn't give access to the handshake of the main one.
+func checkCertExpiry(days int) {
+	netconn, err := dialRaw()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't check the server's certificate: %s\n", err)
+		return
+	}
+	defer netconn.Close()
+
+	tlsconn, ok := netconn.(*tls.Conn)
+	if !ok {
+		return
+	}
+	if err := tlsconn.Handshake(); err != nil {
+		fmt.Fprintf(os.Stderr, "Can't check the server's certificate: %s\n", err)
+		return
+	}
+	certs := tlsconn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return
+	}
+	cert := certs[0]
+
+	if *debug {
+		fmt.Fprintf(os.Stderr, "Certificate issued by %s expires %s\n", cert.Issuer, cert.NotAfter.Format(time.RFC3339))
+	}
+
+	left := time.Until(cert.NotAfter)
+	if left < time.Duration(days)*24*time.Hour {
+		fmt.Fprintf(os.Stderr, "Warning: the server's certificate expires on %s, in %s\n", cert.NotAfter.Format("2006-01-02"), humanDuration(int64(left.Seconds())))
+	}
+}
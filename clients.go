@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// clientcolumns are the CLIENT LIST fields shown in the clients table
+var clientcolumns = []string{"id", "addr", "name", "age", "idle", "db", "cmd"}
+
+// parseClientList splits CLIENT LIST output into one map of fields per client
+func parseClientList(list string) []map[string]string {
+	clients := []map[string]string{}
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		client := map[string]string{}
+		for _, field := range strings.Fields(line) {
+			parts := strings.SplitN(field, "=", 2)
+			if len(parts) == 2 {
+				client[parts[0]] = parts[1]
+			}
+		}
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// clientTable renders CLIENT LIST output as a table, optionally sorted by
+// descending idle time or age
+func clientTable(list string, sortby string) string {
+	clients := parseClientList(list)
+
+	if sortby != "" {
+		sort.SliceStable(clients, func(i, j int) bool {
+			a, _ := strconv.ParseInt(clients[i][sortby], 10, 64)
+			b, _ := strconv.ParseInt(clients[j][sortby], 10, 64)
+			return a > b
+		})
+	}
+
+	rows := make([][]string, len(clients))
+	for i, client := range clients {
+		row := make([]string, len(clientcolumns))
+		for j, column := range clientcolumns {
+			row[j] = client[column]
+		}
+		rows[i] = row
+	}
+
+	return formatTable(clientcolumns, rows)
+}
+
+// renderClientList is the human format for CLIENT LIST replies
+func renderClientList(command []string, reply interface{}) (string, bool) {
+	list, ok := reply.([]byte)
+	if !ok {
+		return "", false
+	}
+	return clientTable(string(list), *clientsort), true
+}
+
+// showClients prints the connected clients as a table
+func showClients() {
+	list, err := redis.String(conn.Do("CLIENT", "LIST"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(clientTable(list, *clientsort))
+}
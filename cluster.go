@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// clusterNode is a node of a Redis Cluster as listed by CLUSTER NODES
+type clusterNode struct {
+	ID    string
+	Addr  string
+	Flags []string
+}
+
+// isMaster reports whether the node is a master
+func (n clusterNode) isMaster() bool {
+	for _, flag := range n.Flags {
+		if flag == "master" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClusterNodes parses CLUSTER NODES output, skipping nodes without an
+// address
+func parseClusterNodes(reply string) []clusterNode {
+	nodes := []clusterNode{}
+	for _, line := range strings.Split(reply, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		addr := strings.SplitN(fields[1], "@", 2)[0]
+		if strings.HasPrefix(addr, ":") {
+			continue
+		}
+		nodes = append(nodes, clusterNode{
+			ID:    fields[0],
+			Addr:  addr,
+			Flags: strings.Split(fields[2], ","),
+		})
+	}
+	return nodes
+}
+
+// clusterMasters discovers the master nodes of the cluster we're connected to
+func clusterMasters() ([]clusterNode, error) {
+	reply, err := redis.String(conn.Do("CLUSTER", "NODES"))
+	if err != nil {
+		return nil, err
+	}
+
+	masters := []clusterNode{}
+	for _, node := range parseClusterNodes(reply) {
+		if node.isMaster() {
+			masters = append(masters, node)
+		}
+	}
+	return masters, nil
+}
+
+// dialNode connects to another node with the same scheme, credentials and
+// TLS settings as the main connection
+func dialNode(addr string) (redis.Conn, error) {
+	u, err := url.Parse(connectionurl)
+	if err != nil {
+		return nil, err
+	}
+	u.Host = addr
+	return dialURL(u.String())
+}
+
+// clusterCall runs a command on every master node, printing each node's
+// reply under its address. Nodes which fail are reported and skipped.
+func clusterCall(command []string) {
+	if len(command) == 0 {
+		log.Fatal("--cluster-call needs a command to run")
+	}
+	if !*dryrun && !confirmProduction(command) {
+		os.Exit(1)
+	}
+
+	masters, err := clusterMasters()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, node := range masters {
+		fmt.Printf("%s:\n", node.Addr)
+		if dryRunCommand(command) {
+			fmt.Println()
+			continue
+		}
+
+		nodeconn, err := dialNode(node.Addr)
+		if err != nil {
+			fmt.Printf("%s\n\n", err)
+			continue
+		}
+
+		reply, err := nodeconn.Do(command[0], interfaceArgs(command[1:])...)
+		nodeconn.Close()
+		if err != nil {
+			if _, ok := err.(redis.Error); !ok {
+				fmt.Printf("%s\n\n", err)
+				continue
+			}
+			reply = err
+		}
+
+		printReply(command, reply)
+		fmt.Println()
+	}
+}
+
+// clusterDBSize prints the DBSIZE of every master node and their total,
+// listing any nodes which couldn't be counted separately
+func clusterDBSize() {
+	masters, err := clusterMasters()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var total int64
+	rows := [][]string{}
+	failures := [][]string{}
+
+	for _, node := range masters {
+		nodeconn, err := dialNode(node.Addr)
+		if err != nil {
+			failures = append(failures, []string{node.Addr, err.Error()})
+			continue
+		}
+
+		size, err := redis.Int64(nodeconn.Do("DBSIZE"))
+		nodeconn.Close()
+		if err != nil {
+			failures = append(failures, []string{node.Addr, err.Error()})
+			continue
+		}
+
+		total += size
+		rows = append(rows, []string{node.Addr, fmt.Sprint(size)})
+	}
+
+	rows = append(rows, []string{"total", fmt.Sprint(total)})
+	fmt.Print(formatTable([]string{"node", "keys"}, rows))
+
+	if len(failures) > 0 {
+		fmt.Printf("\n%d of %d masters could not be counted:\n", len(failures), len(masters))
+		fmt.Print(formatTable([]string{"node", "error"}, failures))
+	}
+}
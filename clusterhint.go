@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/peterh/liner"
+)
+
+// movedTarget returns the node a MOVED error redirects to
+func movedTarget(err error) (string, bool) {
+	rediserr, ok := err.(redis.Error)
+	if !ok {
+		return "", false
+	}
+	fields := strings.Fields(rediserr.Error())
+	if len(fields) != 3 || fields[0] != "MOVED" {
+		return "", false
+	}
+	return fields[2], true
+}
+
+// clusterHint explains the errors a Redis Cluster node gives clients which
+// don't follow its redirects, as redli doesn't, or "" for other errors
+func clusterHint(err error) string {
+	rediserr, ok := err.(redis.Error)
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(rediserr.Error())
+	if len(fields) == 0 {
+		return ""
+	}
+
+	switch fields[0] {
+	case "MOVED":
+		if len(fields) == 3 {
+			return fmt.Sprintf("This server is a Redis Cluster node and slot %s is served by %s, which redli doesn't follow. Use :connect %s to work with that node.", fields[1], fields[2], fields[2])
+		}
+	case "ASK":
+		if len(fields) == 3 {
+			return fmt.Sprintf("This server is a Redis Cluster node and slot %s is being migrated to %s, try again once the migration is done.", fields[1], fields[2])
+		}
+	case "CLUSTERDOWN":
+		return "This server is a Redis Cluster node and the cluster can't serve requests. CLUSTER INFO and CLUSTER SLOTS show what is wrong."
+	}
+	return ""
+}
+
+// followMoved offers to switch to the node a MOVED error points to and rerun
+// the command there, reporting whether it did
+func followMoved(line *liner.State, parts []string, err error) (bool, error) {
+	addr, ok := movedTarget(err)
+	if !ok || line == nil {
+		return false, nil
+	}
+	if !confirm(line, fmt.Sprintf("Connect to %s and rerun %s?", addr, strings.ToUpper(parts[0]))) {
+		return false, nil
+	}
+
+	// Keep the scheme, and the credentials :connect reuses
+	target := "redis://" + addr
+	if u, perr := url.Parse(connectionurl); perr == nil {
+		target = u.Scheme + "://" + addr
+	}
+	before := conn
+	connectCommand([]string{target})
+	if conn == before {
+		return true, err
+	}
+	_, rerr := runCommand(line, parts)
+	return true, rerr
+}
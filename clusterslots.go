@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// clusterSlotCount is the number of hash slots in a Redis Cluster
+const clusterSlotCount = 16384
+
+// renderClusterSlots is the human format for CLUSTER SLOTS, with a row for
+// each node serving each slot range and a note of any slots not covered
+func renderClusterSlots(command []string, reply interface{}) (string, bool) {
+	ranges, err := redis.Values(reply, nil)
+	if err != nil {
+		return "", false
+	}
+
+	var covered [clusterSlotCount]bool
+	rows := [][]string{}
+	for _, r := range ranges {
+		fields, err := redis.Values(r, nil)
+		if err != nil || len(fields) < 3 {
+			return "", false
+		}
+		start, err1 := redis.Int(fields[0], nil)
+		end, err2 := redis.Int(fields[1], nil)
+		if err1 != nil || err2 != nil {
+			return "", false
+		}
+		markSlots(&covered, start, end)
+
+		for i, n := range fields[2:] {
+			node, err := redis.Values(n, nil)
+			if err != nil || len(node) < 2 {
+				return "", false
+			}
+			ip, _ := redis.String(node[0], nil)
+			port, _ := redis.Int(node[1], nil)
+			id := ""
+			if len(node) > 2 {
+				id, _ = redis.String(node[2], nil)
+			}
+			role := "master"
+			if i > 0 {
+				role = "replica"
+			}
+			rows = append(rows, []string{fmt.Sprintf("%d-%d", start, end), role, net.JoinHostPort(ip, strconv.Itoa(port)), id})
+		}
+	}
+
+	return formatTable([]string{"slots", "role", "addr", "id"}, rows) + slotCoverage(&covered), true
+}
+
+// renderClusterShards is the human format for CLUSTER SHARDS, with a row
+// for each node of each shard and a note of any slots not covered
+func renderClusterShards(command []string, reply interface{}) (string, bool) {
+	shards, err := redis.Values(reply, nil)
+	if err != nil {
+		return "", false
+	}
+
+	var covered [clusterSlotCount]bool
+	rows := [][]string{}
+	for _, s := range shards {
+		shard, err := redis.Values(s, nil)
+		if err != nil {
+			return "", false
+		}
+		fields := map[string]interface{}{}
+		for i := 0; i+1 < len(shard); i += 2 {
+			name, _ := redis.String(shard[i], nil)
+			fields[name] = shard[i+1]
+		}
+
+		bounds, err := redis.Ints(fields["slots"], nil)
+		if err != nil || len(bounds)%2 != 0 {
+			return "", false
+		}
+		slots := []string{}
+		for i := 0; i < len(bounds); i += 2 {
+			markSlots(&covered, bounds[i], bounds[i+1])
+			slots = append(slots, fmt.Sprintf("%d-%d", bounds[i], bounds[i+1]))
+		}
+
+		nodes, err := redis.Values(fields["nodes"], nil)
+		if err != nil {
+			return "", false
+		}
+		for _, n := range nodes {
+			node, ok := replyMap(n)
+			if !ok {
+				return "", false
+			}
+			port := node["port"]
+			if port == "" || port == "0" {
+				port = node["tls-port"]
+			}
+			host := node["endpoint"]
+			if host == "" || host == "?" {
+				host = node["ip"]
+			}
+			rows = append(rows, []string{strings.Join(slots, ","), node["role"], net.JoinHostPort(host, port), node["id"], node["health"]})
+		}
+	}
+
+	return formatTable([]string{"slots", "role", "addr", "id", "health"}, rows) + slotCoverage(&covered), true
+}
+
+// markSlots records that the slots from start to end inclusive are served
+func markSlots(covered *[clusterSlotCount]bool, start int, end int) {
+	for slot := start; slot <= end && slot < clusterSlotCount; slot++ {
+		if slot >= 0 {
+			covered[slot] = true
+		}
+	}
+}
+
+// slotCoverage reports the ranges of slots no node serves, which leave
+// part of the keyspace unavailable
+func slotCoverage(covered *[clusterSlotCount]bool) string {
+	gaps := []string{}
+	missing := 0
+	for slot := 0; slot < clusterSlotCount; slot++ {
+		if covered[slot] {
+			continue
+		}
+		start := slot
+		for slot+1 < clusterSlotCount && !covered[slot+1] {
+			slot++
+		}
+		missing += slot - start + 1
+		if start == slot {
+			gaps = append(gaps, strconv.Itoa(start))
+		} else {
+			gaps = append(gaps, fmt.Sprintf("%d-%d", start, slot))
+		}
+	}
+
+	switch missing {
+	case 0:
+		return fmt.Sprintf("All %d slots are covered\n", clusterSlotCount)
+	case 1:
+		return fmt.Sprintf("WARNING: slot %s is not covered\n", gaps[0])
+	}
+	return fmt.Sprintf("WARNING: %d slots are not covered: %s\n", missing, strings.Join(gaps, ", "))
+}
+
+// replyMap turns a flat array of names and values into a map, with numbers
+// as text
+func replyMap(reply interface{}) (map[string]string, bool) {
+	values, ok := reply.([]interface{})
+	if !ok || len(values)%2 != 0 {
+		return nil, false
+	}
+	fields := map[string]string{}
+	for i := 0; i < len(values); i += 2 {
+		name, ok := values[i].([]byte)
+		if !ok {
+			return nil, false
+		}
+		fields[string(name)] = strings.Join(flattenReply(values[i+1]), " ")
+	}
+	return fields, true
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// configfile is where default settings are read from unless --config or
+// --no-config say otherwise
+const configfile = ".redlirc"
+
+// loadConfig reads the config file named in args, or ~/.redlirc, and makes
+// its settings the defaults of the matching flags, so that anything given
+// on the command line wins. Settings from the profile chosen with
+// --profile-name are applied over the top level ones. It has to run before
+// the flags are parsed.
+func loadConfig(args []string) error {
+	path, explicit, profilename := "", false, ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			i = len(args)
+		case arg == "--no-config":
+			return nil
+		case arg == "--config" && i+1 < len(args):
+			path, explicit = args[i+1], true
+			i++
+		case strings.HasPrefix(arg, "--config="):
+			path, explicit = strings.TrimPrefix(arg, "--config="), true
+		case arg == "--profile-name" && i+1 < len(args):
+			profilename = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--profile-name="):
+			profilename = strings.TrimPrefix(arg, "--profile-name=")
+		}
+	}
+
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(home, configfile)
+	}
+
+	sections, err := readConfig(path)
+	if os.IsNotExist(err) && !explicit && profilename == "" {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := applySettings(path, sections[""]); err != nil {
+		return err
+	}
+	if profilename == "" {
+		return nil
+	}
+	settings, ok := sections[profilename]
+	if !ok {
+		return fmt.Errorf("%s: no profile %q", path, profilename)
+	}
+	return applySettings(path, settings)
+}
+
+// repeatablesettings are settings which may be given more than once, every
+// value being kept
+var repeatablesettings = map[string]bool{
+	"on-connect": true,
+}
+
+// applySettings makes settings the defaults of their flags, apart from
+// alias.<name> settings which define aliases. The password is never stored
+// in the file, auth-env and auth-file say where to find it.
+func applySettings(path string, settings [][2]string) error {
+	repeated := map[string][]string{}
+	for _, setting := range settings {
+		name, value := setting[0], setting[1]
+		if strings.HasPrefix(name, "alias.") {
+			if err := defineAlias(strings.TrimPrefix(name, "alias."), value); err != nil {
+				return fmt.Errorf("%s: %s", path, err)
+			}
+			continue
+		}
+
+		switch name {
+		case "auth":
+			return fmt.Errorf("%s: don't store passwords in the config file, use auth-env or auth-file", path)
+		case "auth-env":
+			name, value = "auth", os.Getenv(value)
+		case "auth-file":
+			secret, err := ioutil.ReadFile(value)
+			if err != nil {
+				return err
+			}
+			name, value = "auth", strings.TrimSpace(string(secret))
+		}
+
+		flag := kingpin.CommandLine.GetFlag(name)
+		if flag == nil {
+			return fmt.Errorf("%s: unknown setting %q", path, name)
+		}
+		if repeatablesettings[name] {
+			repeated[name] = append(repeated[name], value)
+			flag.Default(repeated[name]...)
+			continue
+		}
+		flag.Default(value)
+	}
+	return nil
+}
+
+// readConfig reads a file of "name = value" lines, where names are long flag
+// names, skipping blank lines and # comments. Settings are grouped by the
+// [profile.<name>] section they follow, those before any section are keyed
+// by "". A section given twice carries on where it left off.
+func readConfig(path string) (map[string][][2]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sections := map[string][][2]string{}
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if !strings.HasPrefix(name, "profile.") {
+				return nil, fmt.Errorf("%s:%d: expected [profile.<name>]", path, n)
+			}
+			section = strings.TrimPrefix(name, "profile.")
+			if _, ok := sections[section]; !ok {
+				sections[section] = [][2]string{}
+			}
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected name = value", path, n)
+		}
+		name := strings.TrimSpace(parts[0])
+		value := unquoteSetting(strings.TrimSpace(parts[1]))
+		sections[section] = append(sections[section], [2]string{name, value})
+	}
+
+	return sections, scanner.Err()
+}
+
+// unquoteSetting removes the quotes from a value wholly enclosed in a
+// matching pair of them, leaving any other quotes alone
+func unquoteSetting(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
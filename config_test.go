@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeConfig writes a config file for a test to read
+func writeConfig(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "redlirc")
+	if err := os.WriteFile(path, []byte(text), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadConfigQuotes(t *testing.T) {
+	path := writeConfig(t, `
+prompt = "{host} > "
+env = 'prod'
+alias.greet = ECHO "hello world"
+alias.it = ECHO 'it''s'
+raw = "unmatched'
+empty = ""
+quote = "
+`)
+	sections, err := readConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]string{
+		{"prompt", "{host} > "},
+		{"env", "prod"},
+		{"alias.greet", `ECHO "hello world"`},
+		{"alias.it", `ECHO 'it''s'`},
+		{"raw", `"unmatched'`},
+		{"empty", ""},
+		{"quote", `"`},
+	}
+	if !reflect.DeepEqual(sections[""], want) {
+		t.Errorf("readConfig settings\n%q\nwant\n%q", sections[""], want)
+	}
+}
+
+func TestReadConfigRepeatedProfile(t *testing.T) {
+	path := writeConfig(t, `
+host = localhost
+
+[profile.prod]
+host = redis.prod.internal
+on-connect = CLIENT NO-EVICT on
+
+[profile.staging]
+host = redis.staging.internal
+
+[profile.prod]
+on-connect = CONFIG GET maxmemory
+`)
+	sections, err := readConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][][2]string{
+		"": {{"host", "localhost"}},
+		"prod": {
+			{"host", "redis.prod.internal"},
+			{"on-connect", "CLIENT NO-EVICT on"},
+			{"on-connect", "CONFIG GET maxmemory"},
+		},
+		"staging": {{"host", "redis.staging.internal"}},
+	}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("readConfig sections\n%q\nwant\n%q", sections, want)
+	}
+}
+
+func TestReadConfigErrors(t *testing.T) {
+	for _, text := range []string{
+		"[prod]\nhost = x\n",
+		"host\n",
+	} {
+		if _, err := readConfig(writeConfig(t, text)); err == nil {
+			t.Errorf("readConfig(%q) succeeded, want an error", text)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// configdefaults are the values CONFIG GET gives for a server started
+// without a config file, for the settings which are the same in Redis 6.2
+// and 7
+var configdefaults = map[string]string{
+	"activedefrag":                "no",
+	"activerehashing":             "yes",
+	"aof-use-rdb-preamble":        "yes",
+	"appendfilename":              "appendonly.aof",
+	"appendfsync":                 "everysec",
+	"appendonly":                  "no",
+	"auto-aof-rewrite-min-size":   "67108864",
+	"auto-aof-rewrite-percentage": "100",
+	"client-output-buffer-limit":  "normal 0 0 0 slave 268435456 67108864 60 pubsub 33554432 8388608 60",
+	"client-query-buffer-limit":   "1073741824",
+	"cluster-enabled":             "no",
+	"databases":                   "16",
+	"dbfilename":                  "dump.rdb",
+	"dynamic-hz":                  "yes",
+	"hash-max-ziplist-entries":    "128",
+	"hash-max-ziplist-value":      "64",
+	"hz":                          "10",
+	"io-threads":                  "1",
+	"latency-monitor-threshold":   "0",
+	"lazyfree-lazy-eviction":      "no",
+	"lazyfree-lazy-expire":        "no",
+	"lazyfree-lazy-server-del":    "no",
+	"lazyfree-lazy-user-del":      "no",
+	"lfu-decay-time":              "1",
+	"lfu-log-factor":              "10",
+	"list-compress-depth":         "0",
+	"list-max-ziplist-size":       "-2",
+	"loglevel":                    "notice",
+	"lua-time-limit":              "5000",
+	"maxclients":                  "10000",
+	"maxmemory":                   "0",
+	"maxmemory-eviction-tenacity": "10",
+	"maxmemory-policy":            "noeviction",
+	"maxmemory-samples":           "5",
+	"min-replicas-max-lag":        "10",
+	"min-replicas-to-write":       "0",
+	"notify-keyspace-events":      "",
+	"port":                        "6379",
+	"proto-max-bulk-len":          "536870912",
+	"protected-mode":              "yes",
+	"rdbchecksum":                 "yes",
+	"rdbcompression":              "yes",
+	"repl-backlog-size":           "1048576",
+	"repl-diskless-sync-delay":    "5",
+	"repl-timeout":                "60",
+	"replica-read-only":           "yes",
+	"replica-serve-stale-data":    "yes",
+	"save":                        "3600 1 300 100 60 10000",
+	"set-max-intset-entries":      "512",
+	"slowlog-log-slower-than":     "10000",
+	"slowlog-max-len":             "128",
+	"stop-writes-on-bgsave-error": "yes",
+	"stream-node-max-bytes":       "4096",
+	"stream-node-max-entries":     "100",
+	"tcp-backlog":                 "511",
+	"tcp-keepalive":               "300",
+	"timeout":                     "0",
+	"zset-max-ziplist-entries":    "128",
+	"zset-max-ziplist-value":      "64",
+}
+
+// versiondefaults are the defaults which differ between major versions, or
+// which only some of them have, keyed by major version
+var versiondefaults = map[string]map[string]string{
+	"6": {
+		"repl-diskless-sync": "no",
+	},
+	"7": {
+		"appenddirname":             "appendonlydir",
+		"busy-reply-threshold":      "5000",
+		"hash-max-listpack-entries": "128",
+		"hash-max-listpack-value":   "64",
+		"list-max-listpack-size":    "-2",
+		"repl-diskless-sync":        "yes",
+		"zset-max-listpack-entries": "128",
+		"zset-max-listpack-value":   "64",
+	},
+}
+
+// defaultsFor returns the defaults for a server version, using the nearest
+// version known when there are none for its own
+func defaultsFor(version string) (map[string]string, string) {
+	major := strings.SplitN(version, ".", 2)[0]
+	if _, ok := versiondefaults[major]; !ok {
+		if n, err := strconv.Atoi(major); err == nil && n > 7 {
+			major = "7"
+		} else {
+			major = "6"
+		}
+	}
+
+	defaults := map[string]string{}
+	for name, value := range configdefaults {
+		defaults[name] = value
+	}
+	for name, value := range versiondefaults[major] {
+		defaults[name] = value
+	}
+	return defaults, major
+}
+
+// showConfigDiff prints the server's settings which differ from the
+// defaults for its version, along with what the default is
+func showConfigDiff() {
+	info, err := redis.String(conn.Do("INFO", "server"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	version := redisParseInfo(info)["redis_version"]
+
+	settings, err := redis.StringMap(conn.Do("CONFIG", "GET", "*"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defaults, major := defaultsFor(version)
+	if !strings.HasPrefix(version, major+".") {
+		fmt.Printf("No defaults known for Redis %s, comparing with Redis %s\n", version, major)
+	}
+
+	names := []string{}
+	unknown := 0
+	for name, value := range settings {
+		def, ok := defaults[name]
+		if !ok {
+			unknown++
+			continue
+		}
+		if value != def {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		fmt.Println("All known settings have their default values")
+	} else {
+		rows := make([][]string, len(names))
+		for i, name := range names {
+			rows[i] = []string{name, settings[name], defaults[name]}
+		}
+		fmt.Print(formatTable([]string{"setting", "value", "default"}, rows))
+	}
+	if unknown > 0 {
+		fmt.Printf("(%d settings without a known default not compared)\n", unknown)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recordingConn is a redis.Conn which records the commands sent to it and
+// answers them from replies, keyed by the upper case command and its
+// arguments joined with spaces
+type recordingConn struct {
+	commands [][]string
+	replies  map[string]interface{}
+	pending  []string
+}
+
+func (c *recordingConn) record(command string, args []interface{}) string {
+	parts := []string{strings.ToUpper(command)}
+	for _, arg := range args {
+		if b, ok := arg.([]byte); ok {
+			arg = string(b)
+		}
+		parts = append(parts, fmt.Sprint(arg))
+	}
+	c.commands = append(c.commands, parts)
+	return strings.Join(parts, " ")
+}
+
+func (c *recordingConn) Close() error { return nil }
+func (c *recordingConn) Err() error   { return nil }
+
+func (c *recordingConn) Do(command string, args ...interface{}) (interface{}, error) {
+	if command == "" {
+		return nil, nil
+	}
+	return c.reply(c.record(command, args))
+}
+
+func (c *recordingConn) Send(command string, args ...interface{}) error {
+	c.pending = append(c.pending, c.record(command, args))
+	return nil
+}
+
+func (c *recordingConn) Flush() error { return nil }
+
+func (c *recordingConn) Receive() (interface{}, error) {
+	key := c.pending[0]
+	c.pending = c.pending[1:]
+	return c.reply(key)
+}
+
+func (c *recordingConn) reply(key string) (interface{}, error) {
+	reply := c.replies[key]
+	if err, ok := reply.(error); ok {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// useConn swaps in a recordingConn for the connection commands go to until
+// the test ends
+func useConn(t *testing.T, replies map[string]interface{}) *recordingConn {
+	t.Helper()
+	saved := conn
+	recording := &recordingConn{replies: replies}
+	conn = recording
+	t.Cleanup(func() { conn = saved })
+	return recording
+}
+
+// setFlag sets a flag's value until the test ends
+func setFlag[T any](t *testing.T, flag *T, value T) {
+	t.Helper()
+	saved := *flag
+	*flag = value
+	t.Cleanup(func() { *flag = saved })
+}
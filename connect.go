@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// startupauth holds the credentials the session started with, which
+// :connect reuses for URIs without any
+var startupauth struct {
+	saved          bool
+	user, password string
+	userinfo       *url.Userinfo
+}
+
+// connectCommand implements :connect, which switches the session to another
+// server. The old connection is kept if the new one can't be made.
+func connectCommand(args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: :connect <uri>")
+		return
+	}
+
+	if !startupauth.saved {
+		startupauth.saved = true
+		startupauth.user, startupauth.password = authuser, authpassword
+		if u, err := url.Parse(connectionurl); err == nil {
+			startupauth.userinfo = u.User
+		}
+	}
+
+	rawurl, user, password, err := connectURL(args[0])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	newconn, err := dialAs(rawurl, user, password)
+	if err != nil {
+		fmt.Printf("Can't connect: %s\n", err)
+		return
+	}
+
+	reply, err := redis.String(newconn.Do("INFO"))
+	if err != nil {
+		newconn.Close()
+		fmt.Printf("Can't connect: %s\n", err)
+		return
+	}
+
+	conn.Close()
+	conn = newconn
+	connectionurl = rawurl
+	resetSession()
+	authuser, authpassword = user, password
+
+	info := redisParseInfo(reply)
+	setPromptValues(info)
+	if err := setEnvironment(*envname, promptvalues["host"], *envcolors); err != nil {
+		fmt.Println(err)
+	}
+
+	fmt.Printf("Connected to %s\n", info["redis_version"])
+}
+
+// connectURL works out the URL to dial for :connect, and the ACL user and
+// password to send with AUTH. A URI without a scheme is taken as redis://,
+// and one without credentials reuses those the session started with.
+func connectURL(rawurl string) (string, string, string, error) {
+	if !strings.Contains(rawurl, "://") {
+		rawurl = "redis://" + rawurl
+	}
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", "", "", err
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return "", "", "", fmt.Errorf("can't connect to %s URIs, only redis: and rediss:", u.Scheme)
+	}
+
+	// The timeout set at startup carries on, so a timeout in the URI is unused
+	if _, err := uriParams(u); err != nil {
+		return "", "", "", err
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(defaultport))
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if n, err := strconv.Atoi(db); err != nil || n < 0 {
+			return "", "", "", fmt.Errorf("bad database %q in URI", db)
+		}
+	}
+
+	user, password := startupauth.user, startupauth.password
+	if u.User == nil {
+		u.User = startupauth.userinfo
+	} else if user != "" {
+		// As at startup, the URI's username is ignored and --user stays the
+		// ACL user, which we AUTH as ourselves with the URI's password
+		if uripassword, ok := u.User.Password(); ok {
+			password = uripassword
+		}
+		u.User = nil
+	}
+
+	return u.String(), user, password, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// dryRunPrefix marks the commands --dry-run shows instead of sending
+const dryRunPrefix = "(dry run) "
+
+// dryRunCommand prints a command, quoted so it could be pasted back in,
+// instead of it being sent when --dry-run is given, and reports whether it
+// did
+func dryRunCommand(parts []string) bool {
+	if !*dryrun {
+		return false
+	}
+	fmt.Println(dryRunPrefix + quoteCommand(parts))
+	return true
+}
+
+// checkDryRun refuses --dry-run with the modes it can't preview, and
+// otherwise makes clear that nothing is going to be sent. Commands which
+// only read, such as the SCAN which finds keys for --scan-apply, still run.
+func checkDryRun() {
+	modes := []struct {
+		flag string
+		set  bool
+	}{
+		{"--raw-resp", *rawresp},
+		{"--restore", *restore},
+		{"--slowlog-reset", *slowlogreset},
+		{"--enable-notify", *enablenotify},
+		{"--eval", *evalfile != ""},
+		{"--eval-ro", *evalrofile != ""},
+		{"--bench", *bench != ""},
+		{"--lru-test", *lrutest > 0},
+	}
+	for _, mode := range modes {
+		if mode.set {
+			log.Fatalf("--dry-run can't be used with %s", mode.flag)
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Dry run: commands are shown, not sent to the server")
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDryRunSendsNothing(t *testing.T) {
+	setFlag(t, dryrun, true)
+	recording := useConn(t, map[string]interface{}{
+		"CLUSTER NODES": "07c3 10.0.0.1:6379@16379 myself,master - 0 0 1 connected 0-16383\n",
+	})
+	luascripts["touch"] = luaScript{sha: "abc123", source: "return 1"}
+	defer delete(luascripts, "touch")
+
+	runCommand(nil, []string{"DEL", "a"})
+	runCommand(nil, []string{"FLUSHALL"})
+	runScriptCommand([]string{"touch", "0"})
+	watchCommand([]string{"INCR", "counter"})
+	clusterCall([]string{"FLUSHALL"})
+
+	for _, command := range recording.commands {
+		if command[0] != "CLUSTER" {
+			t.Errorf("%v was sent during a dry run", command)
+		}
+	}
+}
+
+func TestDryRunCommand(t *testing.T) {
+	if dryRunCommand([]string{"SET", "a", "1"}) {
+		t.Error("command was held back without --dry-run")
+	}
+	setFlag(t, dryrun, true)
+	if !dryRunCommand([]string{"SET", "a", "1"}) {
+		t.Error("command wasn't held back with --dry-run")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// dumpKey prints the DUMP serialization of a key as base64
+func dumpKey(args []string) {
+	if len(args) != 1 {
+		log.Fatal("--dump needs a single <key>")
+	}
+
+	payload, err := redis.Bytes(conn.Do("DUMP", args[0]))
+	if err == redis.ErrNil {
+		log.Fatalf("Key %s does not exist", args[0])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(base64.StdEncoding.EncodeToString(payload))
+}
+
+// restoreKey decodes a base64 DUMP payload and RESTOREs it into a key
+func restoreKey(args []string, replace bool) {
+	if len(args) != 3 {
+		log.Fatal("--restore needs <key> <ttl> <base64>")
+	}
+
+	ttl, err := strconv.Atoi(args[1])
+	if err != nil {
+		log.Fatal("Bad ttl ", err)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(args[2])
+	if err != nil {
+		log.Fatal("Bad payload ", err)
+	}
+
+	restoreargs := []interface{}{args[0], ttl, payload}
+	if replace {
+		restoreargs = append(restoreargs, "REPLACE")
+	}
+
+	reply, err := conn.Do("RESTORE", restoreargs...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printReply(append([]string{"RESTORE"}, args...), reply)
+}
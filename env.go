@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/peterh/liner"
+)
+
+// ansicolors maps color names usable in --env-colors to ANSI SGR codes
+var ansicolors = map[string]string{
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+}
+
+// dangerouscommands need confirming before running against production
+var dangerouscommands = map[string]bool{
+	"flushall": true,
+	"flushdb":  true,
+	"swapdb":   true,
+	"debug":    true,
+}
+
+// environment is the name of the environment we are connected to, if known
+var environment string
+
+// promptcolor is the ANSI color code the prompt is shown in, if any
+var promptcolor string
+
+// envColorMap parses an --env-colors value of the form "prod=red,dev=green"
+// into an ordered list of environment name fragments and ANSI codes
+func envColorMap(mapping string) ([][2]string, error) {
+	colors := [][2]string{}
+	for _, entry := range strings.Split(mapping, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("bad env color %q, expected name=color", entry)
+		}
+		code, ok := ansicolors[strings.ToLower(strings.TrimSpace(parts[1]))]
+		if !ok {
+			return nil, fmt.Errorf("unknown color %q", parts[1])
+		}
+		colors = append(colors, [2]string{strings.ToLower(strings.TrimSpace(parts[0])), code})
+	}
+	return colors, nil
+}
+
+// setEnvironment works out the environment name, from --env or by matching
+// the host against the color mapping, and the prompt color to go with it
+func setEnvironment(name string, host string, mapping string) error {
+	colors, err := envColorMap(mapping)
+	if err != nil {
+		return err
+	}
+
+	environment = name
+	if environment == "" {
+		for _, c := range colors {
+			if strings.Contains(strings.ToLower(host), c[0]) {
+				environment = c[0]
+				break
+			}
+		}
+	}
+
+	promptcolor = ""
+	for _, c := range colors {
+		if environment != "" && strings.Contains(strings.ToLower(environment), c[0]) {
+			promptcolor = c[1]
+			break
+		}
+	}
+
+	promptvalues["env"] = environment
+	return nil
+}
+
+// isProduction reports whether the environment name looks like production
+func isProduction() bool {
+	return strings.Contains(strings.ToLower(environment), "prod")
+}
+
+// isDangerousCommand reports whether a command is destructive enough to
+// need confirming
+func isDangerousCommand(parts []string) bool {
+	name := strings.ToLower(parts[0])
+	if name == "config" && len(parts) > 1 {
+		return strings.ToLower(parts[1]) == "set"
+	}
+	return dangerouscommands[name]
+}
+
+// confirmProduction asks before a dangerous command runs against production
+// outside the REPL, as one-shot commands and --cluster-call do. Without a
+// terminal to ask on the command is refused.
+func confirmProduction(parts []string) bool {
+	if !isProduction() || !isDangerousCommand(parts) {
+		return true
+	}
+	var line *liner.State
+	if isTerminal(os.Stdin) {
+		line = liner.NewLiner()
+		defer line.Close()
+	}
+	return confirm(line, fmt.Sprintf("Really run %s against %s?", strings.ToUpper(parts[0]), environment))
+}
+
+// confirm asks a yes/no question, defaulting to no. Without a terminal to
+// ask on, when running commands from stdin, the answer is always no.
+func confirm(line *liner.State, question string) bool {
+	if line == nil {
+		fmt.Println(question + " Not confirmed, stdin is not a terminal")
+		return false
+	}
+	answer, err := line.Prompt(question + " (y/N) ")
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// isTerminal reports whether file, usually stdin or stdout, is a terminal.
+// Everything which behaves differently in a pipe decides with this.
+func isTerminal(file *os.File) bool {
+	stat, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor reports whether output should be colored, as chosen with
+// --color, which by default colors output only when it goes to a terminal
+func useColor() bool {
+	switch *colormode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestConfirmProduction(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	setFlag(t, &os.Stdin, r)
+
+	tests := []struct {
+		environment string
+		command     []string
+		want        bool
+	}{
+		{"prod", []string{"FLUSHALL"}, false},
+		{"production-eu", []string{"config", "set", "maxmemory", "0"}, false},
+		{"prod", []string{"GET", "key"}, true},
+		{"prod", []string{"CONFIG", "GET", "maxmemory"}, true},
+		{"staging", []string{"FLUSHALL"}, true},
+		{"", []string{"FLUSHALL"}, true},
+	}
+	for _, test := range tests {
+		setFlag(t, &environment, test.environment)
+		var got bool
+		captureStdout(t, func() { got = confirmProduction(test.command) })
+		if got != test.want {
+			t.Errorf("confirmProduction(%q) against %q = %v, want %v", test.command, test.environment, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// confidenceZ is the z-score for the 95% confidence intervals --estimate-keys
+// reports
+const confidenceZ = 1.96
+
+// keySample is what --estimate-keys learns about one randomly chosen key
+type keySample struct {
+	keytype string
+	size    int64
+	ttl     int64
+}
+
+// estimateKeys samples n random keys with RANDOMKEY and estimates the mix of
+// types, the memory used and how many keys expire across the whole
+// database, without scanning it. Keys are drawn with replacement, so a
+// large key may be counted more than once, as it should be for the
+// estimates to be unbiased.
+func estimateKeys(n int) {
+	if n <= 0 {
+		log.Fatal("--sample-size must be at least 1")
+	}
+
+	dbsize, err := redis.Int64(conn.Do("DBSIZE"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if dbsize == 0 {
+		fmt.Println("Database is empty")
+		return
+	}
+
+	samples, sized, err := sampleKeySizes(n)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(samples) == 0 {
+		fmt.Println("Database is empty")
+		return
+	}
+
+	total := float64(len(samples))
+	fmt.Printf("Estimated from %d random keys of %d, ± figures are 95%% confidence intervals\n\n", len(samples), dbsize)
+
+	counts := map[string]int{}
+	sizes := []int64{}
+	expiring := []int64{}
+	for _, sample := range samples {
+		counts[sample.keytype]++
+		if sized {
+			sizes = append(sizes, sample.size)
+		}
+		if sample.ttl >= 0 {
+			expiring = append(expiring, sample.ttl)
+		}
+	}
+
+	types := make([]string, 0, len(counts))
+	for keytype := range counts {
+		types = append(types, keytype)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if counts[types[i]] != counts[types[j]] {
+			return counts[types[i]] > counts[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	rows := [][]string{}
+	for _, keytype := range types {
+		share, margin := proportion(counts[keytype], len(samples))
+		rows = append(rows, []string{keytype, fmt.Sprintf("%.1f%% ± %.1f%%", share*100, margin*100), "~" + strconv.FormatInt(int64(share*float64(dbsize)), 10)})
+	}
+	fmt.Print(formatTable([]string{"type", "share", "keys"}, rows))
+	fmt.Println()
+
+	if sized {
+		sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+		mean, margin := meanInterval(sizes)
+		fmt.Printf("Memory per key: mean %s ± %s, median %s, 90th percentile %s, 99th percentile %s, largest seen %s\n",
+			humanBytes(int64(mean)), humanBytes(int64(margin)),
+			humanBytes(percentile(sizes, 50)), humanBytes(percentile(sizes, 90)), humanBytes(percentile(sizes, 99)), humanBytes(sizes[len(sizes)-1]))
+		fmt.Printf("Memory for all keys: %s ± %s\n", humanBytes(int64(mean*float64(dbsize))), humanBytes(int64(margin*float64(dbsize))))
+	} else {
+		fmt.Println("Memory per key: unknown, the server doesn't support MEMORY USAGE")
+	}
+
+	share, margin := proportion(len(expiring), len(samples))
+	fmt.Printf("Keys with a TTL: %.1f%% ± %.1f%%, ~%d keys", share*100, margin*100, int64(share*float64(dbsize)))
+	if len(expiring) > 0 {
+		sort.Slice(expiring, func(i, j int) bool { return expiring[i] < expiring[j] })
+		fmt.Printf(", median time left %s", humanDuration(percentile(expiring, 50)/1000))
+	}
+	fmt.Println()
+
+	if int64(n) >= dbsize {
+		fmt.Println("The database has no more keys than the sample; --count-by-type gives exact counts")
+	} else if total < 100 {
+		fmt.Println("The sample is small, so the estimates are rough; raise --sample-size for tighter ones")
+	}
+}
+
+// sampleKeySizes picks n random keys and finds the type, memory usage and
+// TTL of each, pipelining up to --max-inflight commands at a time. It
+// reports whether the server supports MEMORY USAGE.
+func sampleKeySizes(n int) ([]keySample, bool, error) {
+	keys := []string{}
+	for len(keys) < n {
+		batch := n - len(keys)
+		if batch > *maxinflight {
+			batch = *maxinflight
+		}
+		for i := 0; i < batch; i++ {
+			if err := conn.Send("RANDOMKEY"); err != nil {
+				return nil, false, err
+			}
+		}
+		if err := conn.Flush(); err != nil {
+			return nil, false, err
+		}
+		for i := 0; i < batch; i++ {
+			key, err := redis.String(conn.Receive())
+			if err == redis.ErrNil {
+				continue
+			}
+			if err != nil {
+				return nil, false, err
+			}
+			keys = append(keys, key)
+		}
+		fmt.Fprintf(os.Stderr, "\rSampled %d keys", len(keys))
+		if len(keys) == 0 {
+			break
+		}
+	}
+
+	// Each key takes three commands
+	perbatch := *maxinflight / 3
+	if perbatch < 1 {
+		perbatch = 1
+	}
+
+	samples := []keySample{}
+	sized := true
+	for start := 0; start < len(keys); start += perbatch {
+		end := start + perbatch
+		if end > len(keys) {
+			end = len(keys)
+		}
+		for _, key := range keys[start:end] {
+			conn.Send("TYPE", key)
+			conn.Send("MEMORY", "USAGE", key)
+			if err := conn.Send("PTTL", key); err != nil {
+				return nil, false, err
+			}
+		}
+		if err := conn.Flush(); err != nil {
+			return nil, false, err
+		}
+		for range keys[start:end] {
+			keytype, err := redis.String(conn.Receive())
+			if err != nil {
+				return nil, false, err
+			}
+			size, err := redis.Int64(conn.Receive())
+			if _, ok := err.(redis.Error); ok {
+				sized = false
+			} else if err != nil && err != redis.ErrNil {
+				return nil, false, err
+			}
+			ttl, err := redis.Int64(conn.Receive())
+			if err != nil {
+				return nil, false, err
+			}
+			// Keys deleted since RANDOMKEY picked them have type none
+			if keytype != "none" {
+				samples = append(samples, keySample{keytype, size, ttl})
+			}
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+	return samples, sized, nil
+}
+
+// proportion returns the share of a sample that count makes up, with the
+// margin of its 95% confidence interval
+func proportion(count int, total int) (float64, float64) {
+	p := float64(count) / float64(total)
+	return p, confidenceZ * math.Sqrt(p*(1-p)/float64(total))
+}
+
+// meanInterval returns the mean of values with the margin of its 95%
+// confidence interval
+func meanInterval(values []int64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += float64(v)
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+
+	var squares float64
+	for _, v := range values {
+		squares += (float64(v) - mean) * (float64(v) - mean)
+	}
+	stddev := math.Sqrt(squares / float64(len(values)-1))
+	return mean, confidenceZ * stddev / math.Sqrt(float64(len(values)))
+}
+
+// percentile returns the pth percentile of sorted values
+func percentile(sorted []int64, p int) int64 {
+	i := (len(sorted)*p + 99) / 100
+	if i > 0 {
+		i--
+	}
+	return sorted[i]
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// evalArgs splits the arguments given with --eval into keys and arguments,
+// redis-cli style, at a lone comma. Without a comma they are all keys.
+func evalArgs(args []string) ([]string, []string) {
+	for i, arg := range args {
+		if arg == "," {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// evalScript runs the Lua script in path with the keys and arguments in
+// args, using EVAL_RO when readonly so that it can't write and can run on a
+// replica
+func evalScript(path string, args []string, readonly bool) {
+	script, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	command := "EVAL"
+	if readonly {
+		command = "EVAL_RO"
+	}
+
+	keys, argv := evalArgs(args)
+	parts := append([]string{command, string(script), fmt.Sprint(len(keys))}, keys...)
+	parts = append(parts, argv...)
+
+	result, err := conn.Do(parts[0], interfaceArgs(parts[1:])...)
+	if rediserr, ok := err.(redis.Error); ok && readonly && strings.HasPrefix(rediserr.Error(), "ERR unknown command") {
+		fmt.Fprintln(os.Stderr, "This server doesn't support EVAL_RO, which needs Redis 7 or later")
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printReply(parts, result)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// exportKeys writes the commands needed to recreate every key matching
+// pattern to path, either as redli command lines or as RESP
+func exportKeys(path string, pattern string, format string) {
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	out := bufio.NewWriter(file)
+
+	writeCommand := func(args []string) error {
+		if format == "resp" {
+			_, err := out.WriteString(respCommand(args))
+			return err
+		}
+		if !lineSafe(args) {
+			fmt.Fprintf(os.Stderr, "Warning: %s has empty or multi-line values, use --export-format=resp to export it exactly\n", args[1])
+		}
+		_, err := out.WriteString(quoteCommand(args) + "\n")
+		return err
+	}
+
+	exported := 0
+	err = scanKeys(pattern, *scancursor, *scanlimit, func(key string) error {
+		commands, err := recreateKey(key)
+		if err != nil {
+			return err
+		}
+		for _, command := range commands {
+			if err := writeCommand(command); err != nil {
+				return err
+			}
+		}
+		if len(commands) > 0 {
+			exported++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := out.Flush(); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Exported %d keys to %s\n", exported, path)
+}
+
+// recreateKey returns the commands which rebuild a key with its TTL. Keys
+// which vanish while being read produce no commands.
+func recreateKey(key string) ([][]string, error) {
+	keytype, err := redis.String(conn.Do("TYPE", key))
+	if err != nil {
+		return nil, err
+	}
+
+	var command []string
+	switch keytype {
+	case "none":
+		return nil, nil
+	case "string":
+		value, err := redis.String(conn.Do("GET", key))
+		if err != nil {
+			return nil, err
+		}
+		command = []string{"SET", key, value}
+	case "list":
+		values, err := redis.Strings(conn.Do("LRANGE", key, 0, -1))
+		if err != nil {
+			return nil, err
+		}
+		command = append([]string{"RPUSH", key}, values...)
+	case "set":
+		values, err := redis.Strings(conn.Do("SMEMBERS", key))
+		if err != nil {
+			return nil, err
+		}
+		command = append([]string{"SADD", key}, values...)
+	case "hash":
+		values, err := redis.Strings(conn.Do("HGETALL", key))
+		if err != nil {
+			return nil, err
+		}
+		command = append([]string{"HSET", key}, values...)
+	case "zset":
+		values, err := redis.Strings(conn.Do("ZRANGE", key, 0, -1, "WITHSCORES"))
+		if err != nil {
+			return nil, err
+		}
+		command = []string{"ZADD", key}
+		for i := 0; i+1 < len(values); i += 2 {
+			command = append(command, values[i+1], values[i])
+		}
+	case "stream":
+		return recreateStream(key)
+	default:
+		return nil, fmt.Errorf("can't export %s, unsupported type %s", key, keytype)
+	}
+
+	if len(command) == 2 {
+		return nil, nil
+	}
+
+	return withTTL(key, [][]string{command})
+}
+
+// recreateStream returns XADD commands for every entry of a stream
+func recreateStream(key string) ([][]string, error) {
+	entries, err := redis.Values(conn.Do("XRANGE", key, "-", "+"))
+	if err != nil {
+		return nil, err
+	}
+
+	commands := [][]string{}
+	for _, entry := range entries {
+		parts, err := redis.Values(entry, nil)
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("unexpected stream entry in %s", key)
+		}
+		id, err := redis.String(parts[0], nil)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := redis.Strings(parts[1], nil)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, append([]string{"XADD", key, id}, fields...))
+	}
+
+	return withTTL(key, commands)
+}
+
+// withTTL adds a PEXPIRE to commands when key has a TTL
+func withTTL(key string, commands [][]string) ([][]string, error) {
+	ttl, err := redis.Int64(conn.Do("PTTL", key))
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		commands = append(commands, []string{"PEXPIRE", key, strconv.FormatInt(ttl, 10)})
+	}
+	return commands, nil
+}
+
+// respCommand encodes a command as a RESP array of bulk strings
+func respCommand(args []string) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return buf.String()
+}
+
+// quoteCommand joins a command into a line which parses back into the same
+// arguments, quoting those which need it
+func quoteCommand(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// quoteArg double quotes an argument if it contains anything the command
+// line parser would treat specially. A leading @ is doubled, as fileArgs
+// would otherwise read the rest as a file name.
+func quoteArg(arg string) string {
+	if strings.HasPrefix(arg, "@") && len(arg) > 1 {
+		arg = "@" + arg
+	}
+	if arg != "" && !strings.ContainsAny(arg, " \t\r\n\"'`\\;&|<>{") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// lineSafe reports whether a command survives being written as one line
+func lineSafe(args []string) bool {
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, "\r\n") {
+			return false
+		}
+	}
+	return true
+}
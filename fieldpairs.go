@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// helpercommands are meta commands which build a server command from
+// friendlier arguments. The command they build is then run like any other.
+var helpercommands = map[string]func(args []string) ([]string, error){
+	":hset": hsetCommand,
+	":xadd": xaddCommand,
+}
+
+// expandHelper replaces a helper meta command with the command it builds
+func expandHelper(parts []string) ([]string, error) {
+	helper, ok := helpercommands[strings.ToLower(parts[0])]
+	if !ok {
+		return parts, nil
+	}
+	return helper(parts[1:])
+}
+
+// fieldPairs turns field=value arguments into the alternating fields and
+// values XADD and HSET take. Only the first = splits a pair, so values may
+// contain = themselves.
+func fieldPairs(pairs []string) ([]string, error) {
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no field=value pairs given")
+	}
+
+	args := []string{}
+	for _, pair := range pairs {
+		i := strings.Index(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not a field=value pair", pair)
+		}
+		if i == 0 {
+			return nil, fmt.Errorf("%q has no field name", pair)
+		}
+		args = append(args, pair[:i], pair[i+1:])
+	}
+	return args, nil
+}
+
+// xaddCommand builds XADD from :xadd, which adds an entry to a stream from
+// field=value pairs. The entry ID is * unless one is given before the pairs.
+func xaddCommand(args []string) ([]string, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("Usage: :xadd <stream> [id] field=value [field=value ...]")
+	}
+
+	id := "*"
+	pairs := args[1:]
+	if !strings.Contains(pairs[0], "=") {
+		id, pairs = pairs[0], pairs[1:]
+	}
+
+	fields, err := fieldPairs(pairs)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{"XADD", args[0], id}, fields...), nil
+}
+
+// hsetCommand builds HSET from :hset, which sets fields of a hash from
+// field=value pairs
+func hsetCommand(args []string) ([]string, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("Usage: :hset <key> field=value [field=value ...]")
+	}
+
+	fields, err := fieldPairs(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{"HSET", args[0]}, fields...), nil
+}
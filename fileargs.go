@@ -0,0 +1,28 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// fileArgs converts command arguments for sending, replacing each @path
+// with the bytes of that file. An argument starting @@ is sent with the
+// first @ removed, for values which really start with @.
+func fileArgs(parts []string) ([]interface{}, error) {
+	args := make([]interface{}, len(parts))
+	for i, part := range parts {
+		switch {
+		case strings.HasPrefix(part, "@@"):
+			args[i] = part[1:]
+		case strings.HasPrefix(part, "@") && len(part) > 1:
+			contents, err := ioutil.ReadFile(part[1:])
+			if err != nil {
+				return nil, err
+			}
+			args[i] = contents
+		default:
+			args[i] = part
+		}
+	}
+	return args, nil
+}
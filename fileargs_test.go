@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileArgs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "value")
+	if err := os.WriteFile(path, []byte("from a file"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fileArgs([]string{"key", "@" + path, "@@home", "@"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"key", []byte("from a file"), "@home", "@"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fileArgs = %q, want %q", got, want)
+	}
+
+	if _, err := fileArgs([]string{"@" + path + ".missing"}); err == nil {
+		t.Error("fileArgs of a missing file succeeded, want an error")
+	}
+}
+
+func TestQuoteCommandRoundTrip(t *testing.T) {
+	setFlag(t, cliquoting, false)
+	commands := [][]string{
+		{"SET", "key", "@user"},
+		{"SET", "key", "@@twice"},
+		{"SET", "key", "@"},
+		{"SET", "key", "@a b"},
+		{"HSET", "h", "field", `say "hi"`},
+		{"SET", "key", `back\slash`},
+		{"SADD", "s", "{a,b}", "x;y"},
+	}
+	for _, command := range commands {
+		line := quoteCommand(command)
+		parsed, err := splitCommands(line)
+		if err != nil || len(parsed) != 1 {
+			t.Errorf("splitCommands(%q) = %q, %v, want one command", line, parsed, err)
+			continue
+		}
+		args, err := fileArgs(parsed[0][1:])
+		if err != nil {
+			t.Errorf("fileArgs(%q): %s", parsed[0][1:], err)
+			continue
+		}
+		for i, arg := range args {
+			if arg != command[i+1] {
+				t.Errorf("%q quoted as %q sends %q, want %q", command, line, arg, command[i+1])
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// double is a reply which the server sent as a bulk string but which holds a
+// floating point number. The server's text is kept as it is, so no precision
+// is lost to a round trip through float64.
+type double string
+
+// floatcommands reply with a single double
+var floatcommands = map[string]bool{
+	"zscore":       true,
+	"zincrby":      true,
+	"zadd":         true,
+	"incrbyfloat":  true,
+	"hincrbyfloat": true,
+	"geodist":      true,
+}
+
+// parseDouble checks that a reply is a float and returns it in the form the
+// server uses, with infinities as inf and -inf and not a number as nan
+func parseDouble(value []byte) (double, bool) {
+	f, err := strconv.ParseFloat(string(value), 64)
+	if err != nil {
+		// Out of float64's range is still a number the server sent
+		return double(value), isRangeError(err)
+	}
+	switch {
+	case math.IsInf(f, 1):
+		return "inf", true
+	case math.IsInf(f, -1):
+		return "-inf", true
+	case math.IsNaN(f):
+		return "nan", true
+	}
+	return double(value), true
+}
+
+// isRangeError reports whether ParseFloat failed only because the number is
+// too large or small for a float64, which still makes it a valid double
+func isRangeError(err error) bool {
+	numerr, ok := err.(*strconv.NumError)
+	return ok && numerr.Err == strconv.ErrRange
+}
+
+// markDoubles finds the parts of a reply which are doubles: the whole reply
+// of the floatcommands, each element of ZMSCORE, the score of BZPOPMIN and
+// BZPOPMAX, and every second element when scores are paired with members
+func markDoubles(command []string, reply interface{}) interface{} {
+	if len(command) == 0 {
+		return reply
+	}
+
+	name := strings.ToLower(command[0])
+	switch {
+	case floatcommands[name]:
+		return toDouble(reply)
+	case name == "zmscore":
+		return mapElements(reply, func(i int, e interface{}) interface{} { return toDouble(e) })
+	case name == "bzpopmin" || name == "bzpopmax":
+		return mapElements(reply, func(i int, e interface{}) interface{} {
+			if i == 2 {
+				return toDouble(e)
+			}
+			return e
+		})
+	case name == "zpopmin" || name == "zpopmax" || hasWithScores(command):
+		return mapElements(reply, func(i int, e interface{}) interface{} {
+			if i%2 == 1 {
+				return toDouble(e)
+			}
+			return e
+		})
+	}
+	return reply
+}
+
+// hasWithScores reports whether a command asked for members with scores
+func hasWithScores(command []string) bool {
+	if !strings.HasPrefix(strings.ToLower(command[0]), "z") {
+		return false
+	}
+	for _, arg := range command[1:] {
+		if strings.ToLower(arg) == "withscores" {
+			return true
+		}
+	}
+	return false
+}
+
+// toDouble turns a bulk string reply into a double, leaving anything else
+func toDouble(reply interface{}) interface{} {
+	if v, ok := reply.([]byte); ok {
+		if d, ok := parseDouble(v); ok {
+			return d
+		}
+	}
+	return reply
+}
+
+// mapElements applies fn to each element of an array reply
+func mapElements(reply interface{}, fn func(i int, e interface{}) interface{}) interface{} {
+	values, ok := reply.([]interface{})
+	if !ok {
+		return reply
+	}
+	mapped := make([]interface{}, len(values))
+	for i, e := range values {
+		mapped[i] = fn(i, e)
+	}
+	return mapped
+}
+
+// jsonDouble is how a double appears in JSON output: as a number with the
+// server's digits, or as a string for inf, -inf and nan, which JSON can't hold
+func jsonDouble(d double) interface{} {
+	if json.Valid([]byte(d)) {
+		return json.Number(d)
+	}
+	return string(d)
+}
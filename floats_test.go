@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseDouble(t *testing.T) {
+	tests := []struct {
+		value string
+		want  double
+		ok    bool
+	}{
+		{"1.5", "1.5", true},
+		{"-0", "-0", true},
+		{"3", "3", true},
+		{"inf", "inf", true},
+		{"+inf", "inf", true},
+		{"Infinity", "inf", true},
+		{"-inf", "-inf", true},
+		{"nan", "nan", true},
+		{"1e10", "1e10", true},
+		{"2.5E-3", "2.5E-3", true},
+		{"1e400", "1e400", true},
+		{"-1e400", "-1e400", true},
+		{"1e-400", "1e-400", true},
+		{"0.1000000000000000055511151231257827", "0.1000000000000000055511151231257827", true},
+		{"abc", "abc", false},
+		{"", "", false},
+		{"1.5x", "1.5x", false},
+	}
+	for _, test := range tests {
+		got, ok := parseDouble([]byte(test.value))
+		if ok != test.ok || (ok && got != test.want) {
+			t.Errorf("parseDouble(%q) = %q, %v, want %q, %v", test.value, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestMarkDoubles(t *testing.T) {
+	tests := []struct {
+		command []string
+		reply   interface{}
+		want    interface{}
+	}{
+		{[]string{"ZSCORE", "z", "m"}, []byte("inf"), double("inf")},
+		{[]string{"ZSCORE", "z", "m"}, nil, nil},
+		{[]string{"INCRBYFLOAT", "k", "1e3"}, []byte("1000"), double("1000")},
+		{[]string{"GET", "k"}, []byte("1.5"), []byte("1.5")},
+		{
+			[]string{"ZMSCORE", "z", "a", "b", "c"},
+			[]interface{}{[]byte("-inf"), nil, []byte("2.5e-3")},
+			[]interface{}{double("-inf"), nil, double("2.5e-3")},
+		},
+		{
+			[]string{"BZPOPMIN", "z", "0"},
+			[]interface{}{[]byte("z"), []byte("1"), []byte("nan")},
+			[]interface{}{[]byte("z"), []byte("1"), double("nan")},
+		},
+		{
+			[]string{"zrange", "z", "0", "-1", "withscores"},
+			[]interface{}{[]byte("1"), []byte("-inf"), []byte("2"), []byte("1e400")},
+			[]interface{}{[]byte("1"), double("-inf"), []byte("2"), double("1e400")},
+		},
+		{
+			[]string{"ZRANGE", "z", "0", "-1"},
+			[]interface{}{[]byte("1"), []byte("2")},
+			[]interface{}{[]byte("1"), []byte("2")},
+		},
+	}
+	for _, test := range tests {
+		if got := markDoubles(test.command, test.reply); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("markDoubles(%q) = %#v, want %#v", test.command, got, test.want)
+		}
+	}
+}
+
+func TestJSONDouble(t *testing.T) {
+	tests := []struct {
+		value double
+		want  interface{}
+	}{
+		{"1.5", json.Number("1.5")},
+		{"2.5E-3", json.Number("2.5E-3")},
+		{"inf", "inf"},
+		{"-inf", "-inf"},
+		{"nan", "nan"},
+	}
+	for _, test := range tests {
+		if got := jsonDouble(test.value); got != test.want {
+			t.Errorf("jsonDouble(%q) = %#v, want %#v", test.value, got, test.want)
+		}
+	}
+}
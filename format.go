@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Formatter renders a Redis reply as text ready for output
+type Formatter interface {
+	Format(reply interface{}) (string, error)
+}
+
+// formatters maps the --format flag values to their Formatter
+var formatters = map[string]Formatter{
+	"human": humanFormatter{},
+	"json":  jsonFormatter{},
+	"csv":   csvFormatter{},
+	"raw":   rawFormatter{},
+	"jsonl": jsonlFormatter{},
+}
+
+// commandrenderers give the replies of some commands a tailored human format.
+// They are keyed by command name, including the subcommand where there is
+// one, and report false if they can't handle a reply.
+var commandrenderers = map[string]func(command []string, reply interface{}) (string, bool){
+	"client list":          renderClientList,
+	"cluster shards":       renderClusterShards,
+	"cluster slots":        renderClusterSlots,
+	"geopos":               renderGeoPos,
+	"geosearch":            renderGeoSearch,
+	"georadius":            renderGeoSearch,
+	"georadius_ro":         renderGeoSearch,
+	"georadiusbymember":    renderGeoSearch,
+	"georadiusbymember_ro": renderGeoSearch,
+}
+
+// formatter is the Formatter selected with --format
+var formatter Formatter = humanFormatter{}
+
+// isJSONFormat reports whether replies are being printed as JSON
+func isJSONFormat() bool {
+	switch formatter.(type) {
+	case jsonFormatter, jsonlFormatter:
+		return true
+	}
+	return false
+}
+
+// printReply formats and prints the reply to command
+func printReply(command []string, reply interface{}) {
+	reply = markDoubles(command, reply)
+	if _, ok := formatter.(humanFormatter); ok && !*annotate {
+		if *bytesformat == "human" {
+			reply = humanizeBytes(command, reply)
+		}
+		if render, ok := commandrenderers[commandName(command)]; ok {
+			if out, ok := render(command, reply); ok {
+				fmt.Print(out)
+				return
+			}
+		}
+	}
+
+	// Replies gathered from several commands are sorted one by one instead
+	if command != nil {
+		reply = sortedReply(reply)
+	}
+
+	out, err := formatter.Format(reply)
+	if err != nil {
+		fmt.Printf("Could not format reply: %s\n", err)
+		return
+	}
+	fmt.Print(out)
+}
+
+// commandName returns the lower case name of a command, including its
+// subcommand when it has one of the renderers
+func commandName(command []string) string {
+	if len(command) == 0 {
+		return ""
+	}
+	name := strings.ToLower(command[0])
+	if len(command) > 1 {
+		withsub := name + " " + strings.ToLower(command[1])
+		if _, ok := commandrenderers[withsub]; ok {
+			return withsub
+		}
+	}
+	return name
+}
+
+// largeArray is the size from which array replies get a count of their
+// elements in the human format, and are summarised with --summary
+const largeArray = 100
+
+// summaryItems is how many elements --summary shows from each end of an array
+const summaryItems = 5
+
+// humanFormatter is the default, numbered-list style output
+type humanFormatter struct{}
+
+func (humanFormatter) Format(reply interface{}) (string, error) {
+	if *annotate {
+		if out, ok := annotatedValue(reply); ok {
+			return out, nil
+		}
+	}
+
+	switch v := reply.(type) {
+	case redis.Error:
+		return fmt.Sprintf("%s\n", v.Error()), nil
+	case int64:
+		return fmt.Sprintf("%d\n", v), nil
+	case string:
+		return fmt.Sprintf("%s\n", v), nil
+	case double:
+		return fmt.Sprintf("%s\n", v), nil
+	case []byte:
+		if *hexoutput {
+			return hex.Dump(v), nil
+		}
+		return fmt.Sprintf("%s\n", string(v)), nil
+	case nil:
+		return *nullas + "\n", nil
+	case []interface{}:
+		if len(v) < largeArray {
+			return humanArray(v, 0), nil
+		}
+		header := fmt.Sprintf("(%d elements)\n", len(v))
+		if *noindex {
+			header = ""
+		}
+		if !*summary {
+			return header + humanArray(v, 0), nil
+		}
+		last := len(v) - summaryItems
+		return header + humanElements(v[:summaryItems], 0, 1) +
+			fmt.Sprintf("… (%d more) …\n", last-summaryItems) +
+			humanElements(v[last:], 0, last+1), nil
+	}
+	return "", nil
+}
+
+// humanArray numbers the elements of an array, one per line. Nested arrays
+// are numbered in turn, indented to line up under their parent's number.
+func humanArray(values []interface{}, indent int) string {
+	return humanElements(values, indent, 1)
+}
+
+// humanElements numbers a run of array elements, starting at first. With
+// --no-index the elements are printed one per line without numbers, nested
+// arrays included.
+func humanElements(values []interface{}, indent int, first int) string {
+	var buf bytes.Buffer
+	for i, j := range values {
+		if i > 0 {
+			buf.WriteString(strings.Repeat(" ", indent))
+		}
+		prefix := fmt.Sprintf("%d) ", first+i)
+		if *noindex {
+			prefix = ""
+		}
+		buf.WriteString(prefix)
+
+		switch e := j.(type) {
+		case []interface{}:
+			if len(e) == 0 {
+				if !*noindex {
+					buf.WriteString("(empty array)\n")
+				}
+				continue
+			}
+			buf.WriteString(humanArray(e, indent+len(prefix)))
+		case []byte:
+			if *annotate {
+				out, _ := annotatedValue(e)
+				buf.WriteString(out)
+				continue
+			}
+			if *hexoutput {
+				buf.WriteString("\n" + hex.Dump(e))
+				continue
+			}
+			buf.WriteString(string(e) + "\n")
+		default:
+			out, _ := humanFormatter{}.Format(e)
+			buf.WriteString(out)
+		}
+	}
+	return buf.String()
+}
+
+// annotatedValue formats a reply which isn't an array with its type in
+// front, for --annotate. Strings are quoted so that empty strings and
+// spaces show up.
+func annotatedValue(reply interface{}) (string, bool) {
+	switch v := reply.(type) {
+	case redis.Error:
+		return fmt.Sprintf("(error) %s\n", v.Error()), true
+	case int64:
+		return fmt.Sprintf("(integer) %d\n", v), true
+	case string:
+		return fmt.Sprintf("(status) %s\n", v), true
+	case double:
+		return fmt.Sprintf("(double) %s\n", v), true
+	case []byte:
+		if *hexoutput {
+			return "(string)\n" + hex.Dump(v), true
+		}
+		return fmt.Sprintf("(string) %s\n", strconv.Quote(string(v))), true
+	case nil:
+		return "(nil)\n", true
+	}
+	return "", false
+}
+
+// redisCLIFormatter mirrors redis-cli's output, with (integer), (nil) and
+// (error) markers and quoted bulk strings
+type redisCLIFormatter struct{}
+
+func (redisCLIFormatter) Format(reply interface{}) (string, error) {
+	return redisCLIValue(reply, 0), nil
+}
+
+// catRepr quotes a string as redis-cli's sdscatrepr does, escaping quotes,
+// backslashes and the usual control characters with a backslash and every
+// other byte outside printable ASCII, including UTF-8, as \xNN
+func catRepr(value []byte) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for _, c := range value {
+		switch c {
+		case '\\', '"':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\a':
+			buf.WriteString(`\a`)
+		case '\b':
+			buf.WriteString(`\b`)
+		default:
+			if c >= 0x20 && c <= 0x7e {
+				buf.WriteByte(c)
+			} else {
+				fmt.Fprintf(&buf, "\\x%02x", c)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// redisCLIValue formats a reply the way redis-cli does, indenting nested
+// arrays under their parent's number
+func redisCLIValue(reply interface{}, indent int) string {
+	switch v := reply.(type) {
+	case redis.Error:
+		return fmt.Sprintf("(error) %s\n", v.Error())
+	case int64:
+		return fmt.Sprintf("(integer) %d\n", v)
+	case string:
+		return v + "\n"
+	case double:
+		return catRepr([]byte(v)) + "\n"
+	case []byte:
+		return catRepr(v) + "\n"
+	case nil:
+		return "(nil)\n"
+	case []interface{}:
+		if len(v) == 0 {
+			return "(empty array)\n"
+		}
+		width := len(strconv.Itoa(len(v)))
+		var buf bytes.Buffer
+		for i, j := range v {
+			if i > 0 {
+				buf.WriteString(strings.Repeat(" ", indent))
+			}
+			prefix := fmt.Sprintf("%*d) ", width, i+1)
+			buf.WriteString(prefix)
+			buf.WriteString(redisCLIValue(j, indent+len(prefix)))
+		}
+		return buf.String()
+	}
+	return fmt.Sprintf("%v\n", reply)
+}
+
+// rawFormatter prints values bare, one array element per line
+type rawFormatter struct{}
+
+func (rawFormatter) Format(reply interface{}) (string, error) {
+	var buf bytes.Buffer
+	for _, s := range flattenReply(reply) {
+		buf.WriteString(s)
+		buf.WriteString("\n")
+	}
+	return buf.String(), nil
+}
+
+// csvFormatter prints a reply as a single CSV record
+type csvFormatter struct{}
+
+func (csvFormatter) Format(reply interface{}) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(flattenReply(reply)); err != nil {
+		return "", err
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// jsonFormatter prints a reply as an indented JSON document
+type jsonFormatter struct{}
+
+func (jsonFormatter) Format(reply interface{}) (string, error) {
+	jsonbytes, err := json.MarshalIndent(jsonValue(reply), "", " ")
+	if err != nil {
+		return "", err
+	}
+	return string(jsonbytes) + "\n", nil
+}
+
+// jsonlFormatter prints one compact JSON value per line, one per array element
+type jsonlFormatter struct{}
+
+func (jsonlFormatter) Format(reply interface{}) (string, error) {
+	values, ok := reply.([]interface{})
+	if !ok {
+		values = []interface{}{reply}
+	}
+	var buf bytes.Buffer
+	for _, v := range values {
+		jsonbytes, err := json.Marshal(jsonValue(v))
+		if err != nil {
+			return "", err
+		}
+		buf.Write(jsonbytes)
+		buf.WriteString("\n")
+	}
+	return buf.String(), nil
+}
+
+// jsonError is how error replies appear in JSON output, with the error's
+// code, such as ERR or WRONGTYPE, split out
+type jsonError struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// jsonValue converts a reply into values encoding/json renders sensibly
+func jsonValue(reply interface{}) interface{} {
+	switch v := reply.(type) {
+	case redis.Error:
+		return jsonError{Error: v.Error(), Code: strings.SplitN(v.Error(), " ", 2)[0]}
+	case []byte:
+		return string(v)
+	case double:
+		return jsonDouble(v)
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, j := range v {
+			values[i] = jsonValue(j)
+		}
+		return values
+	}
+	return reply
+}
+
+// flattenReply turns a reply, including nested arrays, into a list of strings
+func flattenReply(reply interface{}) []string {
+	switch v := reply.(type) {
+	case redis.Error:
+		return []string{v.Error()}
+	case int64:
+		return []string{fmt.Sprintf("%d", v)}
+	case string:
+		return []string{v}
+	case double:
+		return []string{string(v)}
+	case []byte:
+		return []string{string(v)}
+	case nil:
+		return []string{""}
+	case []interface{}:
+		values := []string{}
+		for _, j := range v {
+			values = append(values, flattenReply(j)...)
+		}
+		return values
+	}
+	return []string{fmt.Sprint(reply)}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// formatTest is a reply and the text a formatter should render it as
+type formatTest struct {
+	name  string
+	reply interface{}
+	want  string
+}
+
+// sampleArray is a reply mixing every kind of value, with a nested array
+var sampleArray = []interface{}{
+	[]byte("one"),
+	int64(2),
+	nil,
+	[]interface{}{[]byte("a"), []byte("b")},
+}
+
+// checkFormat runs a formatter over each test
+func checkFormat(t *testing.T, formatter Formatter, tests []formatTest) {
+	t.Helper()
+	for _, test := range tests {
+		got, err := formatter.Format(test.reply)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHumanFormatter(t *testing.T) {
+	setFlag(t, nullas, "nil")
+	checkFormat(t, humanFormatter{}, []formatTest{
+		{"bulk string", []byte("hello"), "hello\n"},
+		{"status", "OK", "OK\n"},
+		{"integer", int64(42), "42\n"},
+		{"nil", nil, "nil\n"},
+		{"error", redis.Error("ERR wrong"), "ERR wrong\n"},
+		{"double", double("3.5"), "3.5\n"},
+		{"empty array", []interface{}{}, ""},
+		{"array", sampleArray, "1) one\n2) 2\n3) nil\n4) 1) a\n   2) b\n"},
+		{"nested empty array", []interface{}{[]interface{}{}}, "1) (empty array)\n"},
+	})
+}
+
+func TestHumanFormatterNullAs(t *testing.T) {
+	setFlag(t, nullas, "(none)")
+	checkFormat(t, humanFormatter{}, []formatTest{
+		{"nil", nil, "(none)\n"},
+	})
+}
+
+func TestRedisCLIFormatter(t *testing.T) {
+	checkFormat(t, redisCLIFormatter{}, []formatTest{
+		{"bulk string", []byte("hello world"), "\"hello world\"\n"},
+		{"status", "OK", "OK\n"},
+		{"integer", int64(42), "(integer) 42\n"},
+		{"nil", nil, "(nil)\n"},
+		{"error", redis.Error("ERR wrong"), "(error) ERR wrong\n"},
+		{"empty array", []interface{}{}, "(empty array)\n"},
+		{"array", sampleArray, "1) \"one\"\n2) (integer) 2\n3) (nil)\n4) 1) \"a\"\n   2) \"b\"\n"},
+	})
+}
+
+func TestRedisCLIFormatterEscapes(t *testing.T) {
+	checkFormat(t, redisCLIFormatter{}, []formatTest{
+		{"utf-8", []byte("café"), `"caf\xc3\xa9"` + "\n"},
+		{"binary", []byte{0x00, 0x7f, 0xff, 0x1b}, `"\x00\x7f\xff\x1b"` + "\n"},
+		{"control characters", []byte("a\nb\r\t\a\b"), `"a\nb\r\t\a\b"` + "\n"},
+		{"quotes and backslashes", []byte(`say "hi" \o/`), `"say \"hi\" \\o/"` + "\n"},
+		{"double", double("3.5"), `"3.5"` + "\n"},
+		{"nested", []interface{}{[]byte("\xe2\x82\xac")}, `1) "\xe2\x82\xac"` + "\n"},
+	})
+}
+
+func TestJSONFormatter(t *testing.T) {
+	checkFormat(t, jsonFormatter{}, []formatTest{
+		{"bulk string", []byte("hello"), "\"hello\"\n"},
+		{"integer", int64(42), "42\n"},
+		{"nil", nil, "null\n"},
+		{"error", redis.Error("WRONGTYPE bad"), "{\n \"error\": \"WRONGTYPE bad\",\n \"code\": \"WRONGTYPE\"\n}\n"},
+		{"double", double("1.5"), "1.5\n"},
+		{"empty array", []interface{}{}, "[]\n"},
+		{"array", sampleArray, "[\n \"one\",\n 2,\n null,\n [\n  \"a\",\n  \"b\"\n ]\n]\n"},
+	})
+}
+
+func TestJSONLFormatter(t *testing.T) {
+	checkFormat(t, jsonlFormatter{}, []formatTest{
+		{"bulk string", []byte("hello"), "\"hello\"\n"},
+		{"nil", nil, "null\n"},
+		{"error", redis.Error("ERR x"), "{\"error\":\"ERR x\",\"code\":\"ERR\"}\n"},
+		{"empty array", []interface{}{}, ""},
+		{"array", sampleArray, "\"one\"\n2\nnull\n[\"a\",\"b\"]\n"},
+	})
+}
+
+func TestCSVFormatter(t *testing.T) {
+	checkFormat(t, csvFormatter{}, []formatTest{
+		{"bulk string", []byte("hello"), "hello\n"},
+		{"comma", []byte("a,b"), "\"a,b\"\n"},
+		{"integer", int64(42), "42\n"},
+		{"nil", nil, "\n"},
+		{"error", redis.Error("ERR x"), "ERR x\n"},
+		{"array", sampleArray, "one,2,,a,b\n"},
+	})
+}
+
+func TestRawFormatter(t *testing.T) {
+	checkFormat(t, rawFormatter{}, []formatTest{
+		{"bulk string", []byte("hello"), "hello\n"},
+		{"integer", int64(42), "42\n"},
+		{"nil", nil, "\n"},
+		{"error", redis.Error("ERR x"), "ERR x\n"},
+		{"empty array", []interface{}{}, ""},
+		{"array", sampleArray, "one\n2\n\na\nb\n"},
+	})
+}
+
+func TestHumanFormatterHex(t *testing.T) {
+	setFlag(t, hexoutput, true)
+	setFlag(t, nullas, "nil")
+	binary := "00000000  00 ff 41 0a                                       |..A.|\n"
+	long := "00000000  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|\n" +
+		"00000010  58 59                                             |XY|\n"
+	checkFormat(t, humanFormatter{}, []formatTest{
+		{"binary string", []byte{0x00, 0xff, 'A', '\n'}, binary},
+		{"two lines", []byte("0123456789abcdefXY"), long},
+		{"empty string", []byte{}, ""},
+		{"array", []interface{}{[]byte{0x00, 0xff, 'A', '\n'}, int64(7)}, "1) \n" + binary + "2) 7\n"},
+		{"integer", int64(7), "7\n"},
+		{"status", "OK", "OK\n"},
+		{"nil", nil, "nil\n"},
+	})
+}
+
+func TestHumanFormatterCommandReplies(t *testing.T) {
+	setFlag(t, nullas, "nil")
+	lmpop := []interface{}{
+		[]byte("mylist"),
+		[]interface{}{[]byte("three"), []byte("two")},
+	}
+	zmpop := []interface{}{
+		[]byte("myzset"),
+		[]interface{}{
+			[]interface{}{[]byte("one"), []byte("1")},
+			[]interface{}{[]byte("two"), []byte("2")},
+		},
+	}
+	objecthelp := []interface{}{
+		"OBJECT <subcommand> [<arg> [value] [opt] ...]. Subcommands are:",
+		"ENCODING <key>",
+		"FREQ <key>",
+	}
+	checkFormat(t, humanFormatter{}, []formatTest{
+		{"LMPOP", lmpop, "1) mylist\n2) 1) three\n   2) two\n"},
+		{"LMPOP nothing to pop", nil, "nil\n"},
+		{"ZMPOP", zmpop, "1) myzset\n2) 1) 1) one\n      2) 1\n   2) 1) two\n      2) 2\n"},
+		{"SINTERCARD", int64(3), "3\n"},
+		{"OBJECT HELP", objecthelp, "1) OBJECT <subcommand> [<arg> [value] [opt] ...]. Subcommands are:\n" +
+			"2) ENCODING <key>\n3) FREQ <key>\n"},
+	})
+}
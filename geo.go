@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// geoOptions returns which of WITHDIST, WITHHASH and WITHCOORD a GEO
+// command was given
+func geoOptions(command []string) (withdist bool, withhash bool, withcoord bool) {
+	for _, arg := range command[1:] {
+		switch strings.ToUpper(arg) {
+		case "WITHDIST":
+			withdist = true
+		case "WITHHASH":
+			withhash = true
+		case "WITHCOORD":
+			withcoord = true
+		}
+	}
+	return
+}
+
+// geoCoords returns the longitude and latitude of a [longitude, latitude]
+// reply, or dashes for a member with no position
+func geoCoords(reply interface{}) ([]string, bool) {
+	if reply == nil {
+		return []string{"-", "-"}, true
+	}
+	coords, err := redis.Strings(reply, nil)
+	if err != nil || len(coords) != 2 {
+		return nil, false
+	}
+	return coords, true
+}
+
+// renderGeoPos is the human format for GEOPOS, labelling each position
+// with the member it belongs to
+func renderGeoPos(command []string, reply interface{}) (string, bool) {
+	positions, ok := reply.([]interface{})
+	if !ok || len(command) < 2 || len(positions) != len(command)-2 {
+		return "", false
+	}
+
+	rows := [][]string{}
+	for i, position := range positions {
+		coords, ok := geoCoords(position)
+		if !ok {
+			return "", false
+		}
+		rows = append(rows, append([]string{command[i+2]}, coords...))
+	}
+	return formatTable([]string{"member", "longitude", "latitude"}, rows), true
+}
+
+// renderGeoSearch is the human format for GEOSEARCH and GEORADIUS replies
+// given WITHDIST, WITHHASH or WITHCOORD, showing a row per member. The
+// server always puts the distance, hash and coordinates in that order.
+func renderGeoSearch(command []string, reply interface{}) (string, bool) {
+	withdist, withhash, withcoord := geoOptions(command)
+	if !withdist && !withhash && !withcoord {
+		return "", false
+	}
+	members, ok := reply.([]interface{})
+	if !ok {
+		return "", false
+	}
+
+	headers := []string{"member"}
+	if withdist {
+		headers = append(headers, "distance")
+	}
+	if withhash {
+		headers = append(headers, "hash")
+	}
+	if withcoord {
+		headers = append(headers, "longitude", "latitude")
+	}
+
+	rows := [][]string{}
+	for _, member := range members {
+		fields, ok := member.([]interface{})
+		if !ok || len(fields) == 0 {
+			return "", false
+		}
+
+		name, err := redis.String(fields[0], nil)
+		if err != nil {
+			return "", false
+		}
+		row := []string{name}
+		rest := fields[1:]
+
+		if withdist {
+			if len(rest) == 0 {
+				return "", false
+			}
+			dist, err := redis.String(rest[0], nil)
+			if err != nil {
+				return "", false
+			}
+			row = append(row, dist)
+			rest = rest[1:]
+		}
+		if withhash {
+			if len(rest) == 0 {
+				return "", false
+			}
+			hash, err := redis.Int64(rest[0], nil)
+			if err != nil {
+				return "", false
+			}
+			row = append(row, strconv.FormatInt(hash, 10))
+			rest = rest[1:]
+		}
+		if withcoord {
+			if len(rest) == 0 {
+				return "", false
+			}
+			coords, ok := geoCoords(rest[0])
+			if !ok || rest[0] == nil {
+				return "", false
+			}
+			row = append(row, coords...)
+			rest = rest[1:]
+		}
+
+		if len(rest) != 0 {
+			return "", false
+		}
+		rows = append(rows, row)
+	}
+	return formatTable(headers, rows), true
+}
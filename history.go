@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peterh/liner"
+)
+
+// historyfile is the history file in the home directory, shared by all
+// servers unless --per-host-history is given
+const historyfile = ".redli_history"
+
+// addHistory records an input line in the history unless history is turned
+// off or the line could contain a password. Passwords in :connect URIs are
+// hidden instead.
+func addHistory(line *liner.State, input string) {
+	if *nohistory {
+		return
+	}
+	if entry, ok := historyEntry(input); ok {
+		line.AppendHistory(entry)
+	}
+}
+
+// historyEntry returns a line as it should be kept in the history, which is
+// not at all if any of its commands carry a password, checking each of them
+// as redactCommand would. A line which doesn't parse is checked word by word.
+func historyEntry(input string) (string, bool) {
+	commands, err := splitCommands(input)
+	if err != nil {
+		commands = [][]string{strings.Fields(input)}
+	}
+	for _, parts := range commands {
+		if len(parts) == 0 {
+			continue
+		}
+		if strings.ToLower(parts[0]) == ":connect" {
+			for _, arg := range parts[1:] {
+				redacted, ok := redactURI(arg)
+				if !ok {
+					return "", false
+				}
+				input = strings.Replace(input, arg, redacted, -1)
+			}
+			continue
+		}
+		if hasPassword(parts) {
+			return "", false
+		}
+	}
+	return input, true
+}
+
+// historyPath returns where the history is kept, either ~/.redli_history or,
+// per host, ~/.redli_history.d/<host>_<port>. The per host directory is
+// created readable only by the user.
+func historyPath(host string, port string, perhost bool) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	if !perhost {
+		return filepath.Join(home, historyfile), nil
+	}
+
+	dir := filepath.Join(home, historyfile+".d")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	name := strings.NewReplacer("/", "_", ":", "_").Replace(host + "_" + port)
+	return filepath.Join(dir, name), nil
+}
+
+// loadHistory reads the history saved in path, if there is one
+func loadHistory(line *liner.State, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	line.ReadHistory(file)
+}
+
+// saveHistory writes the history to path, readable only by the user
+func saveHistory(line *liner.State, path string) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't save history: %s\n", err)
+		return
+	}
+	defer file.Close()
+	line.WriteHistory(file)
+}
+
+// redactURI hides the password in a URI given to :connect, which may leave
+// out the scheme. It fails for a URI which doesn't parse but could hold
+// credentials.
+func redactURI(rawurl string) (string, bool) {
+	full := rawurl
+	if !strings.Contains(full, "://") {
+		full = "redis://" + full
+	}
+	u, err := url.Parse(full)
+	if err != nil {
+		return rawurl, !strings.Contains(rawurl, "@")
+	}
+	if _, ok := u.User.Password(); !ok {
+		return rawurl, true
+	}
+	redacted := u.Redacted()
+	if full != rawurl {
+		redacted = strings.TrimPrefix(redacted, "redis://")
+	}
+	return redacted, true
+}
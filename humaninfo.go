@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// showHumanInfo prints INFO, for the sections given or the default ones,
+// grouped by section with sizes and durations made readable, followed by
+// metrics worked out from the raw values
+func showHumanInfo(sections []string) {
+	reply, err := redis.String(conn.Do("INFO", interfaceArgs(sections)...))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	info := redisParseInfo(reply)
+	for _, section := range redisParseInfoSections(reply) {
+		printInfoSection(section.name, humanInfoFields(section.fields, info))
+	}
+
+	if derived := derivedInfoMetrics(info); len(derived) > 0 {
+		printInfoSection("Derived", derived)
+	}
+}
+
+// printInfoSection prints a section's name and its fields, lined up
+func printInfoSection(name string, fields [][2]string) {
+	if len(fields) == 0 {
+		return
+	}
+	width := 0
+	for _, field := range fields {
+		if len(field[0]) > width {
+			width = len(field[0])
+		}
+	}
+
+	fmt.Println(name)
+	for _, field := range fields {
+		fmt.Printf("  %-*s  %s\n", width, field[0], field[1])
+	}
+	fmt.Println()
+}
+
+// humanInfoFields makes a section's values readable. Memory sizes are shown
+// in units, dropping the server's own _human copies, and times in seconds
+// become durations.
+func humanInfoFields(fields [][2]string, info map[string]string) [][2]string {
+	readable := [][2]string{}
+	for _, field := range fields {
+		name, value := field[0], field[1]
+		if strings.HasSuffix(name, "_human") {
+			if _, ok := info[strings.TrimSuffix(name, "_human")]; ok {
+				continue
+			}
+		}
+		if name == "uptime_in_days" {
+			continue
+		}
+
+		n, err := strconv.ParseInt(value, 10, 64)
+		switch {
+		case err != nil:
+		case name == "uptime_in_seconds":
+			name, value = "uptime", humanDuration(n)
+		case isMemoryField(name):
+			value = humanBytes(n)
+		}
+		readable = append(readable, [2]string{name, value})
+	}
+	return readable
+}
+
+// isMemoryField reports whether an INFO field is a size in bytes
+func isMemoryField(name string) bool {
+	return (strings.Contains(name, "mem") || strings.HasSuffix(name, "_size")) &&
+		!strings.Contains(name, "ratio")
+}
+
+// derivedInfoMetrics works out figures INFO doesn't give directly: the
+// keyspace hit ratio, how much of maxmemory is used and memory
+// fragmentation
+func derivedInfoMetrics(info map[string]string) [][2]string {
+	derived := [][2]string{}
+	value := func(name string) (float64, bool) {
+		f, err := strconv.ParseFloat(info[name], 64)
+		return f, err == nil
+	}
+
+	if ratio, ok := hitRatio(info); ok {
+		derived = append(derived, [2]string{"hit_ratio", fmt.Sprintf("%.2f%%", ratio)})
+	}
+
+	used, okused := value("used_memory")
+	if max, ok := value("maxmemory"); ok && okused && max > 0 {
+		derived = append(derived, [2]string{"maxmemory_used", fmt.Sprintf("%.2f%%", used*100/max)})
+	}
+
+	if rss, ok := value("used_memory_rss"); ok && okused && used > 0 {
+		fragmentation := fmt.Sprintf("%.2f", rss/used)
+		if rss > used {
+			fragmentation += fmt.Sprintf(" (%s of RSS beyond the data)", humanBytes(int64(rss-used)))
+		}
+		derived = append(derived, [2]string{"fragmentation", fragmentation})
+	} else if ratio, ok := info["mem_fragmentation_ratio"]; ok {
+		derived = append(derived, [2]string{"fragmentation", ratio})
+	}
+
+	return derived
+}
+
+// hitRatio works out the percentage of key lookups which found their key,
+// reporting false when INFO has no stats or there have been no lookups
+func hitRatio(info map[string]string) (float64, bool) {
+	hits, err := strconv.ParseFloat(info["keyspace_hits"], 64)
+	if err != nil {
+		return 0, false
+	}
+	misses, err := strconv.ParseFloat(info["keyspace_misses"], 64)
+	if err != nil || hits+misses == 0 {
+		return 0, false
+	}
+	return hits * 100 / (hits + misses), true
+}
+
+// showHitRatio prints the keyspace hit ratio, exiting with status 1 if it is
+// below threshold
+func showHitRatio(threshold float64) {
+	reply, err := redis.String(conn.Do("INFO", "stats"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ratio, ok := hitRatio(redisParseInfo(reply))
+	if !ok {
+		fmt.Println("No keyspace lookups yet")
+		return
+	}
+
+	fmt.Printf("%.2f%%\n", ratio)
+	if ratio < threshold {
+		fmt.Fprintf(os.Stderr, "Hit ratio is below %.2f%%\n", threshold)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// humanBytes formats a byte count using the largest sensible unit
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit && n > -unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value := float64(n)
+	suffixes := []string{"KB", "MB", "GB", "TB", "PB"}
+	suffix := ""
+	for _, s := range suffixes {
+		value = value / unit
+		suffix = s
+		if value < unit && value > -unit {
+			break
+		}
+	}
+	return fmt.Sprintf("%.2f%s", value, suffix)
+}
+
+// humanizeBytes rewrites replies which carry byte counts so that they also
+// show a readable size. Only MEMORY USAGE and the memory fields of INFO are
+// affected, everything else is returned untouched.
+func humanizeBytes(command []string, reply interface{}) interface{} {
+	if len(command) == 0 {
+		return reply
+	}
+
+	switch strings.ToLower(command[0]) {
+	case "memory":
+		if len(command) > 1 && strings.ToLower(command[1]) == "usage" {
+			if n, ok := reply.(int64); ok {
+				return fmt.Sprintf("%d (%s)", n, humanBytes(n))
+			}
+		}
+	case "info":
+		if v, ok := reply.([]byte); ok {
+			return []byte(humanizeInfoBytes(string(v)))
+		}
+	}
+
+	return reply
+}
+
+// infobytefields are the INFO fields which hold a size in bytes
+var infobytefields = map[string]bool{
+	"used_memory":               true,
+	"used_memory_rss":           true,
+	"used_memory_peak":          true,
+	"used_memory_overhead":      true,
+	"used_memory_startup":       true,
+	"used_memory_dataset":       true,
+	"used_memory_lua":           true,
+	"used_memory_vm_eval":       true,
+	"used_memory_scripts_eval":  true,
+	"used_memory_vm_functions":  true,
+	"used_memory_vm_total":      true,
+	"used_memory_functions":     true,
+	"used_memory_scripts":       true,
+	"total_system_memory":       true,
+	"maxmemory":                 true,
+	"allocator_allocated":       true,
+	"allocator_active":          true,
+	"allocator_resident":        true,
+	"allocator_muzzy":           true,
+	"allocator_frag_bytes":      true,
+	"allocator_rss_bytes":       true,
+	"rss_overhead_bytes":        true,
+	"mem_fragmentation_bytes":   true,
+	"repl_backlog_size":         true,
+	"aof_rewrite_buffer_length": true,
+	"rdb_last_cow_size":         true,
+	"aof_last_cow_size":         true,
+	"module_fork_last_cow_size": true,
+	"current_cow_size":          true,
+	"current_cow_peak":          true,
+}
+
+// humanizeInfoBytes appends a readable size to the infobytefields which the
+// server doesn't already provide a _human variant of
+func humanizeInfoBytes(reply string) string {
+	info := redisParseInfo(reply)
+	lines := strings.Split(reply, "\r\n")
+	for i, line := range lines {
+		parts := strings.Split(line, ":")
+		if len(parts) != 2 || !infobytefields[parts[0]] {
+			continue
+		}
+		if _, ok := info[parts[0]+"_human"]; ok {
+			continue
+		}
+		n, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		lines[i] = fmt.Sprintf("%s (%s)", line, humanBytes(n))
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+// humanAgo describes how long ago a time was, e.g. "5m ago"
+func humanAgo(t time.Time, now time.Time) string {
+	d := now.Sub(t)
+	if d < 0 {
+		return "in the future"
+	}
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
+
+// humanDuration describes a number of seconds in days, hours, minutes and
+// seconds, e.g. "1d 2h 3m 4s", leaving out leading units which are zero
+func humanDuration(seconds int64) string {
+	units := []struct {
+		suffix string
+		size   int64
+	}{{"d", 86400}, {"h", 3600}, {"m", 60}, {"s", 1}}
+
+	parts := []string{}
+	for _, unit := range units {
+		n := seconds / unit.size
+		seconds = seconds % unit.size
+		if n > 0 || len(parts) > 0 || unit.size == 1 {
+			parts = append(parts, fmt.Sprintf("%d%s", n, unit.suffix))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatTime renders a timestamp as chosen with --time-format, as unix
+// seconds, RFC 3339 or relative to now. By default times are relative when
+// writing to a terminal and unix seconds otherwise.
+func formatTime(t time.Time) string {
+	format := *timeformat
+	if format == "" {
+		format = "unix"
+		if isTerminal(os.Stdout) {
+			format = "relative"
+		}
+	}
+
+	switch format {
+	case "rfc3339":
+		return t.Format(time.RFC3339)
+	case "relative":
+		return humanAgo(t, time.Now())
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
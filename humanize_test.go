@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHumanizeInfoBytes(t *testing.T) {
+	reply := strings.Join([]string{
+		"# Memory",
+		"used_memory:1048576",
+		"used_memory_human:1.00M",
+		"used_memory_overhead:2048",
+		"allocator_frag_bytes:512",
+		"mem_fragmentation_ratio:1.25",
+		"mem_clients_slaves:0",
+		"mem_not_counted_for_evict:4096",
+		"mem_allocator:jemalloc-5.3.0",
+		"number_of_cached_scripts:3",
+		"lazyfree_pending_objects:2048",
+	}, "\r\n")
+	want := strings.Join([]string{
+		"# Memory",
+		"used_memory:1048576",
+		"used_memory_human:1.00M",
+		"used_memory_overhead:2048 (2.00KB)",
+		"allocator_frag_bytes:512 (512B)",
+		"mem_fragmentation_ratio:1.25",
+		"mem_clients_slaves:0",
+		"mem_not_counted_for_evict:4096",
+		"mem_allocator:jemalloc-5.3.0",
+		"number_of_cached_scripts:3",
+		"lazyfree_pending_objects:2048",
+	}, "\r\n")
+	if got := humanizeInfoBytes(reply); got != want {
+		t.Errorf("humanizeInfoBytes got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"time"
+)
+
+// idleTimer ends a session left waiting at the prompt for too long, so an
+// unattended terminal doesn't keep a connection open. It only runs while
+// the prompt is waiting, so long running commands don't count as idle.
+type idleTimer struct {
+	after time.Duration
+	timer *time.Timer
+}
+
+// newIdleTimer returns a timer which calls expire once the prompt has
+// waited for after, or nil if after is zero
+func newIdleTimer(after time.Duration, expire func()) *idleTimer {
+	if after <= 0 {
+		return nil
+	}
+	timer := time.AfterFunc(after, expire)
+	timer.Stop()
+	return &idleTimer{after: after, timer: timer}
+}
+
+// waiting starts the timer as the prompt is shown
+func (t *idleTimer) waiting() {
+	if t != nil {
+		t.timer.Reset(t.after)
+	}
+}
+
+// active stops the timer when input arrives
+func (t *idleTimer) active() {
+	if t != nil {
+		t.timer.Stop()
+	}
+}
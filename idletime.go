@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// showidletime is set by :idletime to show how long keys had been idle
+var showidletime bool
+
+// idletimeCommand implements :idletime, warning when the server's eviction
+// policy means it doesn't track idle time
+func idletimeCommand(args []string) {
+	toggle("idletime", &showidletime)(args)
+	if !showidletime {
+		return
+	}
+	if policy, err := getServerConfig("maxmemory-policy"); err == nil && strings.Contains(policy, "lfu") {
+		fmt.Printf("maxmemory-policy is %s, the server doesn't track idle time with LFU policies\n", policy)
+	}
+}
+
+// singleKey returns the key a command operates on, if it takes exactly one
+// key as its first argument
+func singleKey(parts []string) (string, bool) {
+	if len(parts) < 2 {
+		return "", false
+	}
+
+	commanddata, ok := rediscommands[strings.ToLower(parts[0])]
+	if !ok || len(commanddata.Arguments) == 0 {
+		return "", false
+	}
+
+	keys := 0
+	for _, a := range commanddata.Arguments {
+		if a.Type == "key" {
+			if a.Multiple {
+				return "", false
+			}
+			keys++
+		}
+	}
+
+	if keys != 1 || commanddata.Arguments[0].Type != "key" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// keyIdleTime returns a note of how long a command's key has been idle, to
+// be shown after its reply. It must be read before the command runs, as
+// running the command resets the idle time.
+func keyIdleTime(parts []string) string {
+	if !showidletime {
+		return ""
+	}
+
+	key, ok := singleKey(parts)
+	if !ok {
+		return ""
+	}
+
+	idle, err := redis.Int64(conn.Do("OBJECT", "IDLETIME", key))
+	if err != nil {
+		return ""
+	}
+	lastused := time.Now().Add(-time.Duration(idle) * time.Second)
+	return fmt.Sprintf("(%s last used %s)", key, formatTime(lastused))
+}
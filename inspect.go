@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// sizecommands maps key types to the command which counts their elements
+var sizecommands = map[string]string{
+	"string": "STRLEN",
+	"list":   "LLEN",
+	"set":    "SCARD",
+	"zset":   "ZCARD",
+	"hash":   "HLEN",
+	"stream": "XLEN",
+}
+
+// inspectKey prints a short report of a key's type, TTL, encoding, memory
+// use and size
+func inspectKey(key string) {
+	keytype, err := redis.String(conn.Do("TYPE", key))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if keytype == "none" {
+		fmt.Printf("Key %s does not exist\n", key)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Key:      %s\n", key)
+	fmt.Printf("Type:     %s\n", keytype)
+	fmt.Printf("Encoding: %s\n", inspectValue(redis.String(conn.Do("OBJECT", "ENCODING", key))))
+
+	ttl, err := redis.Int64(conn.Do("TTL", key))
+	switch {
+	case err != nil:
+		fmt.Printf("TTL:      %s\n", err)
+	case ttl < 0:
+		fmt.Printf("TTL:      none\n")
+	default:
+		fmt.Printf("TTL:      %ds\n", ttl)
+	}
+
+	memory, err := redis.Int64(conn.Do("MEMORY", "USAGE", key))
+	switch {
+	case err != nil:
+		fmt.Printf("Memory:   %s\n", err)
+	case *bytesformat == "human":
+		fmt.Printf("Memory:   %d (%s)\n", memory, humanBytes(memory))
+	default:
+		fmt.Printf("Memory:   %d\n", memory)
+	}
+
+	if sizecommand, ok := sizecommands[keytype]; ok {
+		size, err := redis.Int64(conn.Do(sizecommand, key))
+		fmt.Printf("Size:     %s\n", inspectValue(strconv.FormatInt(size, 10), err))
+	}
+}
+
+// inspectValue returns a value, or the error that stopped us getting it
+func inspectValue(value string, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return value
+}
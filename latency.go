@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// latencyAlertInterval is the gap between PINGs while watching latency
+const latencyAlertInterval = time.Second
+
+// latencyAlert PINGs the server forever, printing a timestamped line each
+// time a round-trip takes longer than thresholdms milliseconds
+func latencyAlert(thresholdms int, exitonalert bool) {
+	threshold := time.Duration(thresholdms) * time.Millisecond
+
+	fmt.Printf("Watching PING latency, alerting above %v\n", threshold)
+
+	for {
+		start := time.Now()
+		_, err := conn.Do("PING")
+		elapsed := time.Since(start)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if elapsed > threshold {
+			fmt.Printf("%s PING took %v (threshold %v)\n", start.Format("2006-01-02 15:04:05"), elapsed.Round(time.Microsecond), threshold)
+			if exitonalert {
+				os.Exit(1)
+			}
+		}
+
+		time.Sleep(latencyAlertInterval)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// lruPipeline is how many commands --lru-test sends in each round-trip
+const lruPipeline = 250
+
+// lruCycle is how often --lru-test reports its hit rate
+const lruCycle = time.Second
+
+// lruTest simulates a cache over nkeys keys forever, in the same way as
+// redis-cli --lru-test. Keys are picked following a power law so a small
+// set of them is hot, half the commands are GETs and half SETs, and the
+// GET hit rate is reported every cycle. With maxmemory set this shows how
+// well the eviction policy keeps the hot keys.
+func lruTest(nkeys int) {
+	fmt.Printf("Simulating a cache over %d keys, press Ctrl-C to stop\n", nkeys)
+
+	for {
+		hits, misses := 0, 0
+		start := time.Now()
+		for time.Since(start) < lruCycle {
+			gets := make([]bool, lruPipeline)
+			for i := range gets {
+				key := fmt.Sprintf("lru:%d", powerLawRand(1, int64(nkeys), 6.2))
+				gets[i] = rand.Intn(2) == 0
+				if gets[i] {
+					conn.Send("GET", key)
+				} else {
+					conn.Send("SET", key, "val")
+				}
+			}
+			if err := conn.Flush(); err != nil {
+				log.Fatal(err)
+			}
+
+			for _, get := range gets {
+				reply, err := conn.Receive()
+				if _, ok := err.(redis.Error); err != nil && !ok {
+					log.Fatal(err)
+				}
+				if !get {
+					continue
+				}
+				if reply == nil {
+					misses++
+				} else {
+					hits++
+				}
+			}
+		}
+
+		total := hits + misses
+		fmt.Printf("%d Gets/sec | Hits: %d (%.2f%%) | Misses: %d (%.2f%%)\n",
+			int(float64(total)/time.Since(start).Seconds()),
+			hits, percent(hits, total), misses, percent(misses, total))
+	}
+}
+
+// powerLawRand returns a number between min and max, inclusive, which is
+// much more likely to be near min the larger alpha is
+func powerLawRand(min int64, max int64, alpha float64) int64 {
+	max++
+	r := rand.Float64()
+	pl := math.Pow((math.Pow(float64(max), alpha+1)-math.Pow(float64(min), alpha+1))*r+
+		math.Pow(float64(min), alpha+1), 1.0/(alpha+1))
+	return (max - 1 - int64(pl)) + min
+}
+
+// percent returns n as a percentage of total
+func percent(n int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
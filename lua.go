@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// luaScript is a script loaded with :load
+type luaScript struct {
+	sha    string
+	source string
+}
+
+// luascripts holds the scripts loaded with :load by name
+var luascripts = map[string]luaScript{}
+
+// loadCommand implements :load <file> [name], sending a Lua script to the
+// server with SCRIPT LOAD and remembering its SHA under name, which is the
+// file name without its extension by default
+func loadCommand(args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Println("Usage: :load <file> [name]")
+		return
+	}
+
+	source, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	name := strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+	if len(args) == 2 {
+		name = args[1]
+	}
+
+	sha, err := redis.String(conn.Do("SCRIPT", "LOAD", source))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	luascripts[name] = luaScript{sha: sha, source: string(source)}
+	fmt.Printf("Loaded %s as %s\n", name, sha)
+}
+
+// runScriptCommand implements :run <name> numkeys [key ...] [arg ...],
+// calling a script loaded with :load by its SHA. If the server has lost the
+// script, after a restart or SCRIPT FLUSH, it is loaded again and rerun.
+func runScriptCommand(args []string) {
+	if len(args) < 2 {
+		fmt.Println("Usage: :run <name> numkeys [key ...] [arg ...]")
+		return
+	}
+
+	script, ok := luascripts[args[0]]
+	if !ok {
+		fmt.Printf("No script called %s, use :load first\n", args[0])
+		return
+	}
+
+	parts := append([]string{"EVALSHA", script.sha}, args[1:]...)
+	if dryRunCommand(parts) {
+		return
+	}
+	result, err := conn.Do(parts[0], interfaceArgs(parts[1:])...)
+	if rediserr, ok := err.(redis.Error); ok && strings.HasPrefix(rediserr.Error(), "NOSCRIPT") {
+		if _, err := conn.Do("SCRIPT", "LOAD", script.source); err != nil {
+			fmt.Println(err)
+			return
+		}
+		result, err = conn.Do(parts[0], interfaceArgs(parts[1:])...)
+	}
+	if rediserr, ok := err.(redis.Error); ok {
+		result = rediserr
+	} else if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	printReply(parts, result)
+}
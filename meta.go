@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// metacommands are commands starting with ':' which redli handles itself
+// rather than sending to the server
+var metacommands = map[string]func(args []string){
+	":alias":    aliasCommand,
+	":annotate": toggle("annotate", annotate),
+	":connect":  connectCommand,
+	":watch":    watchCommand,
+	":hex":      toggle("hex", hexoutput),
+	":echo":     toggle("echo", echo),
+	":profile":  profileCommand,
+	":idletime": idletimeCommand,
+	":load":     loadCommand,
+	":pipeline": pipelineCommand,
+	":run":      runScriptCommand,
+	":timeout":  timeoutCommand,
+}
+
+// runMetaCommand runs parts if it is a meta command, reporting whether it was
+func runMetaCommand(parts []string) bool {
+	if !strings.HasPrefix(parts[0], ":") {
+		return false
+	}
+
+	metacommand, ok := metacommands[strings.ToLower(parts[0])]
+	if !ok {
+		fmt.Printf("Unknown meta command %s\n", parts[0])
+		return true
+	}
+
+	metacommand(parts[1:])
+	return true
+}
+
+// toggle returns a meta command which turns a setting on or off, or flips
+// it when given no argument
+func toggle(name string, setting *bool) func(args []string) {
+	return func(args []string) {
+		switch {
+		case len(args) == 0:
+			*setting = !*setting
+		case strings.ToLower(args[0]) == "on":
+			*setting = true
+		case strings.ToLower(args[0]) == "off":
+			*setting = false
+		default:
+			fmt.Printf("Usage: :%s [on|off]\n", name)
+			return
+		}
+
+		if *setting {
+			fmt.Printf("%s is on\n", name)
+		} else {
+			fmt.Printf("%s is off\n", name)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// migrateProgressEvery is how many keys are copied between progress reports
+const migrateProgressEvery = 1000
+
+// migrateKeys copies every key matching pattern to the server at desturl
+// using DUMP and RESTORE, keeping each key's remaining TTL
+func migrateKeys(desturl string, pattern string, replace bool) {
+	// The destination has its own TLS settings, but comes from --bind too
+	dest, err := redis.DialURL(desturl, redis.DialNetDial(netdialer.Dial))
+	if err != nil {
+		log.Fatal("Dial destination ", err)
+	}
+	defer dest.Close()
+
+	migrated := 0
+	failed := 0
+
+	err = scanKeys(pattern, *scancursor, *scanlimit, func(key string) error {
+		ttl, err := redis.Int64(conn.Do("PTTL", key))
+		if err != nil {
+			return err
+		}
+		if ttl == -2 {
+			return nil // Key expired or was deleted since the scan found it
+		}
+		if ttl < 0 {
+			ttl = 0
+		}
+
+		if *dryrun {
+			fmt.Printf("%s%s <DUMP of %s>", dryRunPrefix, quoteCommand([]string{"RESTORE", key, strconv.FormatInt(ttl, 10)}), key)
+			if replace {
+				fmt.Print(" REPLACE")
+			}
+			fmt.Println()
+			migrated++
+			return nil
+		}
+
+		payload, err := redis.Bytes(conn.Do("DUMP", key))
+		if err == redis.ErrNil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		restoreargs := []interface{}{key, ttl, payload}
+		if replace {
+			restoreargs = append(restoreargs, "REPLACE")
+		}
+
+		if _, err := dest.Do("RESTORE", restoreargs...); err != nil {
+			fmt.Printf("%s: %s\n", key, err)
+			failed++
+			return nil
+		}
+
+		migrated++
+		if migrated%migrateProgressEvery == 0 {
+			fmt.Printf("Migrated %d keys\n", migrated)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *dryrun {
+		fmt.Printf("Would migrate %d keys\n", migrated)
+		return
+	}
+	fmt.Printf("Migrated %d keys, %d failed\n", migrated, failed)
+}
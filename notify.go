@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// notifyKeyspaceEvents is the notify-keyspace-events setting used by
+// --enable-notify; keyevent notifications for all event classes
+const notifyKeyspaceEvents = "EA"
+
+// watchKeyEvents subscribes to the keyevent notifications for db and prints
+// each event as it arrives until interrupted
+func watchKeyEvents(db int, enable bool) {
+	if enable {
+		if _, err := conn.Do("CONFIG", "SET", "notify-keyspace-events", notifyKeyspaceEvents); err != nil {
+			log.Fatal("Enabling notifications ", err)
+		}
+	} else if events, err := getServerConfig("notify-keyspace-events"); err == nil && !strings.Contains(events, "E") {
+		fmt.Fprintf(os.Stderr, "Keyevent notifications are off, notify-keyspace-events is %q; use --enable-notify to turn them on\n", events)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		conn.Close()
+		os.Exit(0)
+	}()
+
+	psc := redis.PubSubConn{Conn: conn}
+	pattern := fmt.Sprintf("__keyevent@%d__:*", db)
+	if err := psc.PSubscribe(pattern); err != nil {
+		log.Fatal(err)
+	}
+
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			event := v.Channel[strings.Index(v.Channel, ":")+1:]
+			fmt.Printf("%-10s %s\n", event, string(v.Data))
+		case redis.Subscription:
+			fmt.Printf("Watching key events on %s\n", v.Channel)
+		case error:
+			log.Fatal(v)
+		}
+	}
+}
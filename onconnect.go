@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/peterh/liner"
+)
+
+// quietreplies hides the replies of commands which succeed, while the
+// --on-connect commands run with --quiet
+var quietreplies bool
+
+// runOnConnect runs the --on-connect commands, which set up the session,
+// as if they were the first ones entered. A command which fails is reported
+// and the session carries on.
+func runOnConnect(line *liner.State, commands []string) {
+	quietreplies = *quiet
+	defer func() { quietreplies = false }()
+
+	for _, command := range commands {
+		parts, err := splitCommands(command)
+		if err != nil {
+			fmt.Printf("Can't parse --on-connect command %q: %s\n", command, err)
+			continue
+		}
+		runCommands(line, parts)
+	}
+}
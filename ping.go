@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// pingServer sends a PING, with an optional message to echo, and exits
+// with a status reflecting whether the server answered
+func pingServer(message []string) {
+	reply, err := conn.Do("PING", interfaceArgs(message)...)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	printReply(append([]string{"PING"}, message...), reply)
+	os.Exit(0)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// queuedCommand is a command held back by :pipeline begin
+type queuedCommand struct {
+	parts []string
+	args  []interface{}
+}
+
+// pipelining is set between :pipeline begin and :pipeline exec or discard,
+// while pipelined holds the commands entered in the meantime
+var (
+	pipelining bool
+	pipelined  []queuedCommand
+)
+
+// pipelineCommand implements :pipeline, which queues the commands entered
+// after :pipeline begin and sends them all at once on :pipeline exec. Unlike
+// MULTI the commands aren't atomic, they just share one round trip.
+func pipelineCommand(args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: :pipeline begin|exec|discard")
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "begin":
+		if pipelining {
+			fmt.Printf("Already pipelining, %d commands queued\n", len(pipelined))
+			return
+		}
+		pipelining = true
+		pipelined = nil
+		fmt.Println("Queuing commands until :pipeline exec")
+	case "exec":
+		if !pipelining {
+			fmt.Println("Not pipelining, start with :pipeline begin")
+			return
+		}
+		execPipeline()
+	case "discard":
+		if !pipelining {
+			fmt.Println("Not pipelining, start with :pipeline begin")
+			return
+		}
+		fmt.Printf("Discarded %d queued commands\n", len(pipelined))
+		pipelining = false
+		pipelined = nil
+	default:
+		fmt.Println("Usage: :pipeline begin|exec|discard")
+	}
+}
+
+// queueCommand holds a command back for :pipeline exec, reporting whether
+// a pipeline was being built to queue it in
+func queueCommand(parts []string, args []interface{}) bool {
+	if !pipelining {
+		return false
+	}
+	pipelined = append(pipelined, queuedCommand{parts, args})
+	return true
+}
+
+// execPipeline sends the queued commands, then prints each reply in turn
+func execPipeline() {
+	queued := pipelined
+	pipelining = false
+	pipelined = nil
+
+	if len(queued) == 0 {
+		fmt.Println("No commands queued")
+		return
+	}
+
+	start := time.Now()
+	for _, command := range queued {
+		if err := conn.Send(command.parts[0], command.args...); err != nil {
+			fmt.Println(err)
+			reconnect()
+			return
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		fmt.Println(err)
+		reconnect()
+		return
+	}
+
+	for i, command := range queued {
+		var reply interface{}
+		var err error
+		if *cmdtimeout > 0 {
+			reply, err = redis.ReceiveWithTimeout(conn, *cmdtimeout)
+		} else {
+			reply, err = conn.Receive()
+		}
+		logCommand(command.parts, reply, err)
+
+		fmt.Printf("%d. %s\n", i+1, quoteCommand(command.parts))
+		if rediserr, ok := err.(redis.Error); ok {
+			reply = rediserr
+		} else if err != nil {
+			if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+				err = fmt.Errorf("no reply within %v", *cmdtimeout)
+			}
+			fmt.Println(err)
+			fmt.Printf("%d replies not read\n", len(queued)-i-1)
+			reconnect()
+			return
+		}
+		printReply(command.parts, reply)
+	}
+	fmt.Printf("Sent %d commands in one round trip in %v\n", len(queued), time.Since(start).Round(time.Microsecond))
+}
+
+// pipelinePrompt is shown before the prompt while commands are queued
+func pipelinePrompt() string {
+	if !pipelining {
+		return ""
+	}
+	return fmt.Sprintf("(pipeline: %d queued) ", len(pipelined))
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"time"
+)
+
+// latencyHistogram counts latencies in log-linear buckets, in the style of
+// an HDR histogram, so percentiles stay accurate to a few percent however
+// wide the range of latencies
+type latencyHistogram struct {
+	buckets map[uint64]int64
+	count   int64
+	min     time.Duration
+	max     time.Duration
+}
+
+// profiles holds a latency histogram for each command run with --profile
+var profiles = map[string]*latencyHistogram{}
+
+// bucketFloor rounds microseconds down to the bottom of its bucket, keeping
+// five significant bits
+func bucketFloor(micros uint64) uint64 {
+	if micros < 32 {
+		return micros
+	}
+	shift := uint(bits.Len64(micros) - 5)
+	return micros >> shift << shift
+}
+
+func (h *latencyHistogram) record(d time.Duration) {
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.buckets[bucketFloor(uint64(d/time.Microsecond))]++
+}
+
+// merge adds the samples of another histogram to h
+func (h *latencyHistogram) merge(other *latencyHistogram) {
+	if other.count == 0 {
+		return
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+	h.count += other.count
+	for floor, n := range other.buckets {
+		h.buckets[floor] += n
+	}
+}
+
+// percentile returns the latency below which p percent of samples fall
+func (h *latencyHistogram) percentile(p float64) time.Duration {
+	floors := make([]uint64, 0, len(h.buckets))
+	for floor := range h.buckets {
+		floors = append(floors, floor)
+	}
+	sort.Slice(floors, func(i, j int) bool { return floors[i] < floors[j] })
+
+	target := int64(float64(h.count)*p/100 + 0.5)
+	if target < 1 {
+		target = 1
+	}
+	var seen int64
+	for _, floor := range floors {
+		seen += h.buckets[floor]
+		if seen >= target {
+			d := time.Duration(floor) * time.Microsecond
+			if d < h.min {
+				return h.min
+			}
+			if d > h.max {
+				return h.max
+			}
+			return d
+		}
+	}
+	return h.max
+}
+
+// recordLatency adds a command's round-trip time to its profile
+func recordLatency(command string, d time.Duration) {
+	name := strings.ToUpper(command)
+	h, ok := profiles[name]
+	if !ok {
+		h = &latencyHistogram{buckets: map[uint64]int64{}}
+		profiles[name] = h
+	}
+	h.record(d)
+}
+
+// printProfile prints the latency statistics of every command profiled
+func printProfile() {
+	if len(profiles) == 0 {
+		fmt.Println("No commands profiled")
+		return
+	}
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := [][]string{}
+	for _, name := range names {
+		h := profiles[name]
+		rows = append(rows, []string{
+			name,
+			fmt.Sprint(h.count),
+			h.min.String(),
+			h.percentile(50).String(),
+			h.percentile(99).String(),
+			h.max.String(),
+		})
+	}
+
+	fmt.Print(formatTable([]string{"command", "count", "min", "p50", "p99", "max"}, rows))
+}
+
+// profileCommand implements :profile, printing the statistics so far, or
+// clearing them with :profile reset
+func profileCommand(args []string) {
+	if len(args) > 0 && strings.ToLower(args[0]) == "reset" {
+		profiles = map[string]*latencyHistogram{}
+		fmt.Println("Profile reset")
+		return
+	}
+	printProfile()
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/peterh/liner"
+)
+
+// promptplaceholders are the names which may appear in --prompt-template
+var promptplaceholders = map[string]bool{
+	"host":    true,
+	"port":    true,
+	"db":      true,
+	"role":    true,
+	"version": true,
+	"env":     true,
+}
+
+var placeholderpattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// promptvalues holds the current value of each prompt placeholder
+var promptvalues = map[string]string{}
+
+// validatePromptTemplate checks that a template only uses known placeholders
+func validatePromptTemplate(template string) error {
+	for _, placeholder := range placeholderpattern.FindAllString(template, -1) {
+		if !promptplaceholders[strings.Trim(placeholder, "{}")] {
+			return fmt.Errorf("unknown placeholder %s in prompt template", placeholder)
+		}
+	}
+	return nil
+}
+
+// setPromptValues records the connection details shown in the prompt
+func setPromptValues(info map[string]string) {
+	u, err := url.Parse(connectionurl)
+	if err != nil {
+		u = &url.URL{}
+	}
+
+	db := strings.TrimPrefix(u.Path, "/")
+	if db == "" {
+		db = "0"
+	}
+
+	promptvalues = map[string]string{
+		"host":    u.Hostname(),
+		"port":    u.Port(),
+		"db":      db,
+		"role":    info["role"],
+		"version": info["redis_version"],
+		"env":     environment,
+	}
+}
+
+func getPrompt() string {
+	template := *prompttmpl
+	if template == "" {
+		if *longprompt {
+			template = "{host}:{port}> "
+		} else {
+			template = "> "
+		}
+	}
+
+	return pipelinePrompt() + placeholderpattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return promptvalues[strings.Trim(placeholder, "{}")]
+	})
+}
+
+// readLine shows the prompt, in the environment's color if it has one, and
+// returns the line entered. Ctrl-C returns liner.ErrPromptAborted.
+func readLine(line *liner.State) (string, error) {
+	if promptcolor == "" || !useColor() {
+		return line.Prompt(getPrompt())
+	}
+
+	fmt.Print("\x1b[" + promptcolor + "m")
+	defer fmt.Print("\x1b[0m")
+	return line.Prompt(getPrompt())
+}
+
+// promptAborted reports whether readLine was stopped by Ctrl-C, which should
+// discard the line rather than end the session
+func promptAborted(err error) bool {
+	return err == liner.ErrPromptAborted
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// errUnbalancedQuotes is returned for lines redis-cli would reject
+var errUnbalancedQuotes = errors.New("unbalanced quotes or a closing quote not followed by a space")
+
+// splitArgs splits a line into arguments exactly as redis-cli does, following
+// sdssplitargs. Double quoted strings take \n, \r, \t, \b, \a and \xNN
+// escapes, and a backslash before any other character stands for that
+// character. Single quoted strings are literal apart from \'. A closing
+// quote must be followed by a space or the end of the line.
+func splitArgs(line string) ([]string, error) {
+	args := []string{}
+	p := 0
+	for {
+		for p < len(line) && isCLISpace(line[p]) {
+			p++
+		}
+		if p == len(line) {
+			return args, nil
+		}
+
+		var current []byte
+		inquotes, insingle, done := false, false, false
+		for !done {
+			switch {
+			case inquotes:
+				switch {
+				case p == len(line):
+					return nil, errUnbalancedQuotes
+				case line[p] == '\\' && p+3 < len(line) && line[p+1] == 'x' && isHexDigit(line[p+2]) && isHexDigit(line[p+3]):
+					b, _ := strconv.ParseUint(line[p+2:p+4], 16, 8)
+					current = append(current, byte(b))
+					p += 3
+				case line[p] == '\\' && p+1 < len(line):
+					p++
+					current = append(current, unescapeCLI(line[p]))
+				case line[p] == '"':
+					if p+1 < len(line) && !isCLISpace(line[p+1]) {
+						return nil, errUnbalancedQuotes
+					}
+					done = true
+				default:
+					current = append(current, line[p])
+				}
+			case insingle:
+				switch {
+				case p == len(line):
+					return nil, errUnbalancedQuotes
+				case line[p] == '\\' && p+1 < len(line) && line[p+1] == '\'':
+					p++
+					current = append(current, '\'')
+				case line[p] == '\'':
+					if p+1 < len(line) && !isCLISpace(line[p+1]) {
+						return nil, errUnbalancedQuotes
+					}
+					done = true
+				default:
+					current = append(current, line[p])
+				}
+			default:
+				switch {
+				case p == len(line) || strings.IndexByte(" \n\r\t", line[p]) >= 0:
+					done = true
+				case line[p] == '"':
+					inquotes = true
+				case line[p] == '\'':
+					insingle = true
+				default:
+					current = append(current, line[p])
+				}
+			}
+			if p < len(line) {
+				p++
+			}
+		}
+		args = append(args, string(current))
+	}
+}
+
+// unescapeCLI returns the character a backslash escape in double quotes
+// stands for
+func unescapeCLI(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'a':
+		return '\a'
+	}
+	return c
+}
+
+// isCLISpace matches the C isspace which sdssplitargs uses between arguments
+func isCLISpace(c byte) bool {
+	return strings.IndexByte(" \t\n\v\f\r", c) >= 0
+}
+
+// isHexDigit reports whether c is a hex digit
+func isHexDigit(c byte) bool {
+	return strings.IndexByte("0123456789abcdefABCDEF", c) >= 0
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", []string{}},
+		{"   ", []string{}},
+		{"SET key value", []string{"SET", "key", "value"}},
+		{"  SET\tkey   value  ", []string{"SET", "key", "value"}},
+		{`SET key "hello world"`, []string{"SET", "key", "hello world"}},
+		{`SET key ""`, []string{"SET", "key", ""}},
+		{`SET key ''`, []string{"SET", "key", ""}},
+		{`SET key "a\nb"`, []string{"SET", "key", "a\nb"}},
+		{`SET key "\r\t\b\a"`, []string{"SET", "key", "\r\t\b\a"}},
+		{`SET key "\x41\x62c"`, []string{"SET", "key", "Abc"}},
+		{`SET key "\x00\xff"`, []string{"SET", "key", "\x00\xff"}},
+		{`SET key "\x4g"`, []string{"SET", "key", "x4g"}},
+		{`SET key "\"quoted\""`, []string{"SET", "key", `"quoted"`}},
+		{`SET key "back\\slash"`, []string{"SET", "key", `back\slash`}},
+		{`SET key "\q"`, []string{"SET", "key", "q"}},
+		{`SET key 'a\nb'`, []string{"SET", "key", `a\nb`}},
+		{`SET key 'it\'s'`, []string{"SET", "key", "it's"}},
+		{`SET key 'say "hi"'`, []string{"SET", "key", `say "hi"`}},
+		{`SET key "it's"`, []string{"SET", "key", "it's"}},
+		{`SET key a"b c"`, []string{"SET", "key", "ab c"}},
+		{`SET key \x41`, []string{"SET", "key", `\x41`}},
+	}
+	for _, test := range tests {
+		got, err := splitArgs(test.line)
+		if err != nil {
+			t.Errorf("splitArgs(%q): %s", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestSplitArgsErrors(t *testing.T) {
+	lines := []string{
+		`SET key "unterminated`,
+		`SET key 'unterminated`,
+		`SET key "closing"quote`,
+		`SET key 'closing'quote`,
+		`SET key "a""b"`,
+		`SET key "ends with \"`,
+	}
+	for _, line := range lines {
+		if got, err := splitArgs(line); err != errUnbalancedQuotes {
+			t.Errorf("splitArgs(%q) = %q, %v, want %v", line, got, err, errUnbalancedQuotes)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-shellwords"
+	"github.com/peterh/liner"
+)
+
+// rawReplyWait is how long to wait for more reply bytes before showing them
+const rawReplyWait = 250 * time.Millisecond
+
+// rawRESPSession is a debugging REPL which talks to the server over a plain
+// socket. Each line is sent as a RESP command, or verbatim if it starts with
+// a RESP type byte, and whatever comes back is shown as a hex dump.
+func rawRESPSession() {
+	netconn, err := dialRaw()
+	if err != nil {
+		log.Fatal("Dial ", err)
+	}
+	defer netconn.Close()
+
+	fmt.Println("Raw RESP mode: replies are not interpreted. Commands are sent as typed,")
+	fmt.Println(`lines starting with *, $, +, - or : are sent verbatim with \r\n escapes.`)
+
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+
+	buf := make([]byte, 64*1024)
+	for {
+		input, err := line.Prompt("raw> ")
+		if err != nil {
+			return
+		}
+		if strings.TrimSpace(input) == "" {
+			continue
+		}
+		addHistory(line, input)
+
+		frame, err := rawFrame(input)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		if _, err := netconn.Write([]byte(frame)); err != nil {
+			log.Fatal(err)
+		}
+
+		reply := []byte{}
+		for {
+			netconn.SetReadDeadline(time.Now().Add(rawReplyWait))
+			n, err := netconn.Read(buf)
+			reply = append(reply, buf[:n]...)
+			if err != nil {
+				if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+					break
+				}
+				fmt.Print(hex.Dump(reply))
+				log.Fatal(err)
+			}
+		}
+
+		if len(reply) == 0 {
+			fmt.Println("(no reply)")
+			continue
+		}
+		fmt.Print(hex.Dump(reply))
+	}
+}
+
+// dialRaw opens a plain socket, or a TLS one for rediss: URLs, to the
+// server, for modes which can't go through a redis.Conn
+func dialRaw() (net.Conn, error) {
+	u, err := url.Parse(connectionurl)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "rediss" {
+		config := &tls.Config{ServerName: u.Hostname()}
+		if tlsconfig != nil {
+			config = tlsconfig.Clone()
+			config.ServerName = u.Hostname()
+		}
+		return tls.DialWithDialer(netdialer, "tcp", u.Host, config)
+	}
+	return netdialer.Dial("tcp", u.Host)
+}
+
+// rawFrame turns an input line into the bytes to send
+func rawFrame(input string) (string, error) {
+	if strings.ContainsAny(input[:1], "*$+-:") {
+		frame, err := strconv.Unquote(`"` + strings.Replace(input, `"`, `\"`, -1) + `"`)
+		if err != nil {
+			return "", fmt.Errorf("bad escape in frame: %s", err)
+		}
+		return frame, nil
+	}
+
+	parts, err := shellwords.Parse(input)
+	if err != nil {
+		return "", err
+	}
+	return respCommand(parts), nil
+}
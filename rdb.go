@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rdbProgressInterval is how often --rdb reports how much it has received
+const rdbProgressInterval = time.Second
+
+// dumpRDB saves a snapshot of the server to path by asking for a full
+// resynchronisation with SYNC, as a replica would, and writing out the RDB
+// payload the server replies with
+func dumpRDB(path string) {
+	netconn, err := dialRaw()
+	if err != nil {
+		log.Fatal("Dial ", err)
+	}
+	defer netconn.Close()
+	r := bufio.NewReader(netconn)
+
+	if err := rawAuth(netconn, r); err != nil {
+		log.Fatal(err)
+	}
+
+	// Servers which know rdb-only stop after the RDB rather than going on to
+	// stream writes. Older ones refuse it, which does no harm.
+	if _, err := rawCommand(netconn, r, "REPLCONF", "rdb-only", "1"); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := netconn.Write([]byte(respCommand([]string{"SYNC"}))); err != nil {
+		log.Fatal(err)
+	}
+
+	preamble, err := rdbPreamble(r)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	progress := &rdbProgress{w: file, last: time.Now()}
+	if strings.HasPrefix(preamble, "EOF:") {
+		err = copyUntilMark(progress, r, []byte(strings.TrimPrefix(preamble, "EOF:")))
+	} else {
+		progress.size, err = strconv.ParseInt(preamble, 10, 64)
+		if err == nil {
+			_, err = io.CopyN(progress, r, progress.size)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "\rTransferred %s to %s\n", humanBytes(progress.written), path)
+}
+
+// rdbPreamble reads the server's reply to SYNC up to the payload, skipping
+// the newlines sent to keep the connection alive while the RDB is produced.
+// It returns the payload's length, or EOF:<mark> when the length isn't known
+// and the payload ends with the mark instead.
+func rdbPreamble(r *bufio.Reader) (string, error) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "-"):
+			return "", fmt.Errorf("SYNC failed: %s", line[1:])
+		case strings.HasPrefix(line, "$"):
+			return line[1:], nil
+		}
+		return "", fmt.Errorf("unexpected reply to SYNC: %q", line)
+	}
+}
+
+// copyUntilMark copies the payload to w, holding back the last bytes read
+// until it is clear whether they are the mark which ends it
+func copyUntilMark(w io.Writer, r io.Reader, mark []byte) error {
+	buf := make([]byte, 64*1024)
+	held := []byte{}
+	for {
+		n, err := r.Read(buf)
+		held = append(held, buf[:n]...)
+		if bytes.HasSuffix(held, mark) {
+			_, werr := w.Write(held[:len(held)-len(mark)])
+			return werr
+		}
+		if len(held) > len(mark) {
+			if _, werr := w.Write(held[:len(held)-len(mark)]); werr != nil {
+				return werr
+			}
+			held = append([]byte{}, held[len(held)-len(mark):]...)
+		}
+		if err == io.EOF {
+			return fmt.Errorf("connection closed before the end of the RDB")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// rdbProgress writes to w, reporting the bytes written so far on stderr
+type rdbProgress struct {
+	w       io.Writer
+	size    int64
+	written int64
+	last    time.Time
+}
+
+func (p *rdbProgress) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if time.Since(p.last) >= rdbProgressInterval {
+		p.last = time.Now()
+		if p.size > 0 {
+			fmt.Fprintf(os.Stderr, "\rTransferred %s of %s", humanBytes(p.written), humanBytes(p.size))
+		} else {
+			fmt.Fprintf(os.Stderr, "\rTransferred %s", humanBytes(p.written))
+		}
+	}
+	return n, err
+}
+
+// rawAuth sends AUTH over a raw connection when there are credentials,
+// whether they came from --user and --auth or the URI
+func rawAuth(netconn net.Conn, r *bufio.Reader) error {
+	args := rawAuthArgs()
+	if len(args) == 0 {
+		return nil
+	}
+
+	reply, err := rawCommand(netconn, r, args...)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(reply, "-") {
+		return fmt.Errorf("AUTH failed: %s", reply[1:])
+	}
+	return nil
+}
+
+// rawAuthArgs returns the AUTH command for the session's credentials, or
+// nothing without a password. URIs built from flags always have a user
+// part, empty without --auth, so like redigo an empty password means no AUTH.
+func rawAuthArgs() []string {
+	if authuser != "" {
+		return []string{"AUTH", authuser, authpassword}
+	}
+	if u, err := url.Parse(connectionurl); err == nil && u.User != nil {
+		if password, _ := u.User.Password(); password != "" {
+			return []string{"AUTH", password}
+		}
+	}
+	return nil
+}
+
+// rawCommand sends a command over a raw connection and returns the single
+// line reply, such as +OK or an error
+func rawCommand(netconn net.Conn, r *bufio.Reader, args ...string) (string, error) {
+	if _, err := netconn.Write([]byte(respCommand(args))); err != nil {
+		return "", err
+	}
+	line, err := r.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// readyPollInterval is how often --wait-for-ready tries the server
+const readyPollInterval = 250 * time.Millisecond
+
+// transientRetryDelay is the wait before retrying after a transient error
+const transientRetryDelay = 500 * time.Millisecond
+
+// transienterrors are error codes for states a server gets out of by
+// itself, loading its data or waiting for its master
+var transienterrors = []string{"LOADING", "MASTERDOWN"}
+
+// isTransientError reports whether an error reply is for a passing state
+func isTransientError(err error) bool {
+	rediserr, ok := err.(redis.Error)
+	if !ok {
+		return false
+	}
+	for _, code := range transienterrors {
+		if strings.HasPrefix(rediserr.Error(), code+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForReady connects and PINGs the server until it answers, printing a
+// dot for each failed try, and gives up after timeout. Failing to connect
+// and transient errors are retried, other errors are returned at once.
+func waitForReady(timeout time.Duration) (redis.Conn, error) {
+	deadline := time.Now().Add(timeout)
+	waited := false
+	defer func() {
+		if waited {
+			fmt.Fprintln(os.Stderr)
+		}
+	}()
+
+	for {
+		newconn, err := dial()
+		if err == nil {
+			_, err = newconn.Do("PING")
+			if err == nil {
+				return newconn, nil
+			}
+			newconn.Close()
+		}
+
+		if _, ok := err.(redis.Error); ok && !isTransientError(err) {
+			return nil, err
+		}
+		if time.Now().Add(readyPollInterval).After(deadline) {
+			return nil, fmt.Errorf("server not ready after %v: %s", timeout, err)
+		}
+
+		if !waited {
+			fmt.Fprint(os.Stderr, "Waiting for server")
+			waited = true
+		}
+		fmt.Fprint(os.Stderr, ".")
+		time.Sleep(readyPollInterval)
+	}
+}
+
+// doRetrying runs a command, retrying it up to retries times while the
+// server replies with a transient error
+func doRetrying(retries int, command string, args ...interface{}) (interface{}, error) {
+	result, err := doWithTimeout(command, args...)
+	for attempt := 1; isTransientError(err) && attempt <= retries; attempt++ {
+		fmt.Fprintf(os.Stderr, "%s, retrying in %v (%d/%d)\n", err, transientRetryDelay, attempt, retries)
+		time.Sleep(transientRetryDelay)
+		result, err = doWithTimeout(command, args...)
+	}
+	return result, err
+}
+
+// isBusyError reports whether an error is the server saying a script is
+// running for too long to serve anything else
+func isBusyError(err error) bool {
+	rediserr, ok := err.(redis.Error)
+	return ok && strings.HasPrefix(rediserr.Error(), "BUSY ")
+}
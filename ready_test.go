@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestDoRetryingKeepsStdoutForReplies(t *testing.T) {
+	recording := useConn(t, map[string]interface{}{
+		"GET key": redis.Error("LOADING Redis is loading the dataset in memory"),
+	})
+	setFlag(t, cmdtimeout, 0)
+
+	var err error
+	out := captureStdout(t, func() {
+		_, err = doRetrying(1, "GET", "key")
+	})
+	if !isTransientError(err) {
+		t.Errorf("doRetrying error %v, want the LOADING error", err)
+	}
+	if len(recording.commands) != 2 {
+		t.Errorf("sent %q, want GET key twice", recording.commands)
+	}
+	if out != "" {
+		t.Errorf("retry note %q went to stdout", out)
+	}
+}
@@ -2,19 +2,20 @@ package main
 
 import (
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
-	"github.com/mattn/go-shellwords"
 	"github.com/peterh/liner"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
@@ -24,23 +25,133 @@ var (
 	longprompt    = kingpin.Flag("long", "Enable long prompt with host/port").Bool()
 	redisurl      = kingpin.Flag("uri", "URI to connect to").Short('u').URL()
 	redishost     = kingpin.Flag("host", "Host to connect to").Short('h').Default("127.0.0.1").String()
-	redisport     = kingpin.Flag("port", "Port to connect to").Short('p').Default("6379").Int()
-	redisauth     = kingpin.Flag("auth", "Password to use when connecting").Short('a').String()
+	redisport     = kingpin.Flag("port", "Port to connect to, 6379 by default").Short('p').Int()
+	redisauth     = kingpin.Flag("auth", "Password to use when connecting").Short('a').Envar("REDIS_PASSWORD").String()
+	redisuser     = kingpin.Flag("user", "ACL username to use when connecting").Envar("REDIS_USER").String()
 	redisdb       = kingpin.Flag("ndb", "Redis database to access").Short('n').Default("0").Int()
 	redistls      = kingpin.Flag("tls", "Enable TLS/SSL").Default("false").Bool()
 	rediscertfile = kingpin.Flag("certfile", "Self-signed certificate file for validation").Envar("REDIS_CERTFILE").File()
 	rediscertb64  = kingpin.Flag("certb64", "Self-signed certificate string as base64 for validation").Envar("REDIS_CERTB64").String()
+	rediscertpem  = kingpin.Flag("cert", "Self-signed certificate as a PEM string for validation").Envar("REDIS_CERT").String()
+	nohostverify  = kingpin.Flag("no-tls-verify-hostname", "Check the server's certificate without checking it is for the host").Bool()
+	outputformat  = kingpin.Flag("format", "Output format for replies (human, json, csv, raw, jsonl)").Default("human").Enum("human", "json", "csv", "raw", "jsonl")
+	connectretry  = kingpin.Flag("connect-retry", "Number of times to retry the initial connection").Default("0").Int()
+	connectdelay  = kingpin.Flag("connect-retry-delay", "Time to wait between connection retries").Default("1s").Duration()
+	hexoutput     = kingpin.Flag("hex", "Show string replies as hex dumps").Bool()
+	bytesformat   = kingpin.Flag("bytes", "Show memory sizes as human or raw byte counts").Default("human").Enum("human", "raw")
+	latencyalert  = kingpin.Flag("latency-alert", "Watch PING latency and report round-trips slower than this many milliseconds").Int()
+	latencyexit   = kingpin.Flag("latency-alert-exit", "Exit with a non-zero status on the first latency alert").Bool()
+	notify        = kingpin.Flag("notify", "Print keyspace events for the selected database as they happen").Bool()
+	enablenotify  = kingpin.Flag("enable-notify", "Turn on keyspace event notifications on the server for --notify").Bool()
+	dump          = kingpin.Flag("dump", "Print the base64 DUMP serialization of the <key> given as argument").Bool()
+	restore       = kingpin.Flag("restore", "RESTORE a key from <key> <ttl> <base64> arguments").Bool()
+	replace       = kingpin.Flag("replace", "Replace existing keys when restoring").Bool()
+	migrate       = kingpin.Flag("migrate", "Copy keys matching --pattern to the server at this URI").URL()
+	pattern       = kingpin.Flag("pattern", "Glob pattern selecting keys for key scanning modes").Default("*").String()
+	inspect       = kingpin.Flag("inspect", "Print the type, TTL, encoding, memory use and size of a key").String()
+	prompttmpl    = kingpin.Flag("prompt-template", "Prompt template using {host}, {port}, {db}, {role}, {version} and {env}").String()
+	envname       = kingpin.Flag("env", "Name of the environment being connected to, e.g. prod or dev").String()
+	envcolors     = kingpin.Flag("env-colors", "Prompt colors for environments as name=color pairs").Default("prod=red,stag=yellow,dev=green").String()
+	exportfile    = kingpin.Flag("export", "Write commands recreating the keys matching --pattern to this file").String()
+	exportformat  = kingpin.Flag("export-format", "Write exported commands as redli command lines or RESP").Default("redli").Enum("redli", "resp")
+	sample        = kingpin.Flag("sample", "Show this many random keys with their type and a preview of their value").Int()
+	rawresp       = kingpin.Flag("raw-resp", "Debug mode sending commands over a plain socket and showing replies as hex").Bool()
+	nohistory     = kingpin.Flag("no-history", "Don't keep a history of entered commands").Bool()
+	timeout       = kingpin.Flag("timeout", "Timeout for connecting, reading and writing, e.g. 5s").Duration()
+	ping          = kingpin.Flag("ping", "PING the server, echoing any argument, and exit non-zero if it fails").Bool()
+	slowlog       = kingpin.Flag("slowlog", "Show this many of the most recent slowlog entries").Int()
+	slowlogreset  = kingpin.Flag("slowlog-reset", "Reset the slowlog, after showing it with --slowlog").Bool()
+	slowlogthresh = kingpin.Flag("slowlog-threshold", "Highlight slowlog entries which took longer than this").Default("100ms").Duration()
+	clients       = kingpin.Flag("clients", "Show the connected clients as a table").Bool()
+	clientsort    = kingpin.Flag("clients-sort", "Sort client tables by idle time or age").Enum("idle", "age")
+	profile       = kingpin.Flag("profile", "Record command latencies instead of showing replies, reporting them on exit").Bool()
+	clustercall   = kingpin.Flag("cluster-call", "Run the command given as arguments on every cluster master").Bool()
+	clusterdbsize = kingpin.Flag("cluster-dbsize", "Count the keys on every cluster master and in total").Bool()
+	nullas        = kingpin.Flag("null-as", "Show nil replies as this string in the human format").Default("nil").String()
+	clicompat     = kingpin.Flag("redis-cli-compat", "Show replies exactly as redis-cli does").Bool()
+	configpath    = kingpin.Flag("config", "Read default settings from this file instead of ~/.redlirc").String()
+	noconfig      = kingpin.Flag("no-config", "Don't read default settings from a config file").Bool()
+	profilename   = kingpin.Flag("profile-name", "Use the settings of this profile from the config file").String()
+	lrutest       = kingpin.Flag("lru-test", "Simulate a cache workload over this many keys and report the hit rate").Int()
+	bench         = kingpin.Flag("bench", "Benchmark this command, reporting its throughput and latencies").String()
+	benchclients  = kingpin.Flag("bench-clients", "Number of connections to run --bench from").Default("4").Int()
+	requests      = kingpin.Flag("requests", "Number of requests to make with --bench").Default("10000").Int()
+	randmax       = kingpin.Flag("rand-max", "Upper bound, exclusive, of the numbers replacing __rand_int__ in --bench").Default("1000000").Int()
+	scanlimit     = kingpin.Flag("limit", "Stop key scanning modes after this many keys").Int()
+	scancursor    = kingpin.Flag("cursor", "SCAN cursor for key scanning modes to start from").Default("0").String()
+	timeformat    = kingpin.Flag("time-format", "Show timestamps as unix seconds, RFC 3339 or relative to now").Enum("unix", "rfc3339", "relative")
+	stoponerror   = kingpin.Flag("stop-on-error", "Stop running piped commands or a --commands-file at the first one that fails").Bool()
+	evalfile      = kingpin.Flag("eval", "Run the Lua script in this file with the keys, a comma, then the arguments given").String()
+	evalrofile    = kingpin.Flag("eval-ro", "Run the Lua script in this file read only with EVAL_RO, taking keys and arguments as --eval").String()
+	perhosthist   = kingpin.Flag("per-host-history", "Keep a separate history file for each host and port").Bool()
+	summary       = kingpin.Flag("summary", "Show only the first and last few elements of large array replies").Bool()
+	echo          = kingpin.Flag("echo", "Print each command line before its replies").Bool()
+	logfile       = kingpin.Flag("log-file", "Append every command and its reply, with timestamps, to this file").String()
+	forceresp2    = kingpin.Flag("force-resp2", "Send HELLO 2 on connecting and refuse HELLO 3").Bool()
+	scanapply     = kingpin.Flag("scan-apply", "Run the --command template on every key matching --pattern").Bool()
+	applycommand  = kingpin.Flag("command", "Command for --scan-apply, with {} standing for each key").String()
+	yes           = kingpin.Flag("yes", "Don't ask for confirmation before changing keys in bulk").Bool()
+	waitready     = kingpin.Flag("wait-for-ready", "Wait up to this long for the server to connect and answer PING, e.g. 30s").Duration()
+	retrytrans    = kingpin.Flag("retry-transient", "Times to retry commands refused with LOADING or MASTERDOWN").Default("5").Int()
+	humaninfo     = kingpin.Flag("human", "Show INFO grouped by section with readable sizes, durations and derived metrics").Bool()
+	hitratio      = kingpin.Flag("hitratio", "Show the keyspace hit ratio from INFO").Bool()
+	hitthreshold  = kingpin.Flag("hitratio-threshold", "Exit with a non-zero status if the --hitratio percentage is below this").Float64()
+	rdbfile       = kingpin.Flag("rdb", "Save an RDB snapshot of the server to this file, fetched with SYNC").String()
+	countbytype   = kingpin.Flag("count-by-type", "Count the keys matching --pattern of each type").Bool()
+	autotls       = kingpin.Flag("auto-tls", "Reconnect with TLS if a plaintext connection finds the server speaks TLS").Bool()
+	configdiff    = kingpin.Flag("config-diff", "Show the server settings which differ from the defaults for its version").Bool()
+	cliquoting    = kingpin.Flag("redis-cli-quoting", "Split commands into arguments exactly as redis-cli does").Bool()
+	annotate      = kingpin.Flag("annotate", "Show the type of each reply and array element in the human format").Bool()
+	bindaddr      = kingpin.Flag("bind", "Local IP address, and optionally port, to connect from").String()
+	cmdtimeout    = kingpin.Flag("command-timeout", "Give up waiting for the reply to a command after this long, e.g. 10s").Duration()
+	commandsfile  = kingpin.Flag("commands-file", "Run the commands in this file, one per line, then exit").String()
+	certexpiry    = kingpin.Flag("check-cert-expiry", "Warn if the server's TLS certificate expires within --cert-expiry-days").Bool()
+	expirydays    = kingpin.Flag("cert-expiry-days", "Days before a certificate expires to warn from with --check-cert-expiry").Default("30").Int()
+	dryrun        = kingpin.Flag("dry-run", "Show the commands which would be sent to change the server, without sending them").Bool()
+	estimatekeys  = kingpin.Flag("estimate-keys", "Estimate the types, sizes and TTLs of all keys from a random sample").Bool()
+	samplesize    = kingpin.Flag("sample-size", "Number of random keys --estimate-keys samples").Default("1000").Int()
+	asserting     = kingpin.Flag("assert", "Check replies in a --commands-file against the ones given after => on each line").Bool()
+	maxinflight   = kingpin.Flag("max-inflight", "Most commands bulk modes like --scan-apply send before waiting for replies").Default("1000").Int()
+	idletimeout   = kingpin.Flag("idle-timeout", "Disconnect and exit after this long with no input at the prompt, e.g. 15m").Duration()
+	colormode     = kingpin.Flag("color", "Color output: auto colors it only for a terminal, always and never force it").Default("auto").Enum("auto", "always", "never")
+	onconnect     = kingpin.Flag("on-connect", "Command to run after connecting, before the prompt; may be repeated").Strings()
+	quiet         = kingpin.Flag("quiet", "Don't show the connection banner, or the replies of --on-connect commands which succeed").Bool()
+	sortreplies   = kingpin.Flag("sort", "Sort the elements of array replies").Bool()
+	sortnumeric   = kingpin.Flag("sort-numeric", "Sort the elements of array replies as numbers").Bool()
+	noindex       = kingpin.Flag("no-index", "Print the elements of array replies one per line without their numbers").Bool()
 	commandargs   = kingpin.Arg("commands", "Redis commands and values").Strings()
 )
 
+// defaultport is used when neither the flags nor a URI give a port, for
+// both redis: and rediss: connections
+const defaultport = 6379
+
 var (
 	rawrediscommands = Commands{}
 	conn             redis.Conn
+	connectionurl    string
+	tlsconfig        *tls.Config
+	authuser         string
+	authpassword     string
+	dialoptions      []redis.DialOption
+	rediscommands    map[string]Command
 )
 
 func main() {
+	if err := loadConfig(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
 	kingpin.Parse()
 
+	formatter = formatters[*outputformat]
+	if *clicompat {
+		formatter = redisCLIFormatter{}
+	}
+
+	if err := validatePromptTemplate(*prompttmpl); err != nil {
+		log.Fatal(err)
+	}
+
 	cert := []byte{}
 
 	if *rediscertfile != nil {
@@ -49,6 +160,12 @@ func main() {
 			log.Fatal(err)
 		}
 		cert = mycert
+	} else if *rediscertpem != "" {
+		mycert, err := pemCert(*rediscertpem)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cert = mycert
 	} else if rediscertb64 != nil {
 		mycert, err := base64.StdEncoding.DecodeString((*rediscertb64))
 		if err != nil {
@@ -57,87 +174,320 @@ func main() {
 		cert = mycert
 	}
 
-	connectionurl := ""
-
 	if *redisurl == nil {
 		// With no URI, build a URI from other flags
+		scheme := "redis"
 		if *redistls {
-			connectionurl = "rediss://"
-		} else {
-			connectionurl = "redis://"
+			scheme = "rediss"
 		}
+		connectionurl = scheme + "://"
 
-		if redisauth != nil {
+		if redisauth != nil && *redisuser == "" {
 			connectionurl = connectionurl + "x:" + *redisauth + "@"
 		}
 
-		connectionurl = connectionurl + *redishost + ":" + strconv.Itoa(*redisport) + "/" + strconv.Itoa(*redisdb)
+		port := *redisport
+		if port == 0 {
+			port = defaultport
+		}
+
+		connectionurl = connectionurl + *redishost + ":" + strconv.Itoa(port) + "/" + strconv.Itoa(*redisdb)
 	} else {
+		uritimeout, err := uriParams(*redisurl)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// --timeout wins over a timeout in the URI
+		if *timeout == 0 {
+			*timeout = uritimeout
+		}
+		if (*redisurl).Port() == "" {
+			(*redisurl).Host = net.JoinHostPort((*redisurl).Hostname(), strconv.Itoa(defaultport))
+		}
+		*redisdb, err = uriDatabase(*redisurl, *redisdb)
+		if err != nil {
+			log.Fatal(err)
+		}
 		connectionurl = (*redisurl).String()
 	}
 
-	// If we have a certificate, then assume TLS
-	if len(cert) > 0 {
+	// URIs can't carry an ACL username, so with one we send AUTH ourselves
+	if *redisuser != "" {
+		authuser = *redisuser
+		authpassword = *redisauth
+		if *redisurl != nil {
+			u := **redisurl
+			if u.User != nil {
+				if password, ok := u.User.Password(); ok {
+					authpassword = password
+				}
+				u.User = nil
+			}
+			connectionurl = u.String()
+		}
+	}
 
-		config := &tls.Config{RootCAs: x509.NewCertPool(),
-			ClientAuth: tls.RequireAndVerifyClientCert}
+	var err error
+	connectionurl, tlsconfig, err = tlsSettings(connectionurl, cert)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if tlsconfig != nil {
+		dialoptions = append(dialoptions, redis.DialTLSConfig(tlsconfig))
+	}
 
-		ok := config.RootCAs.AppendCertsFromPEM(cert)
-		if !ok {
-			log.Fatal("Couldn't load cert data")
+	if *nohostverify {
+		if tlsconfig == nil && !*autotls {
+			log.Fatal("--no-tls-verify-hostname needs a TLS connection")
+		}
+		if tlsconfig != nil {
+			skipHostnameVerification(tlsconfig)
 		}
+		fmt.Fprintln(os.Stderr, "Warning: TLS hostname verification is off")
+	}
+
+	if *timeout > 0 {
+		dialoptions = append(dialoptions,
+			redis.DialConnectTimeout(*timeout),
+			redis.DialReadTimeout(*timeout),
+			redis.DialWriteTimeout(*timeout))
+	}
 
-		var err error
-		conn, err = redis.DialURL(connectionurl, redis.DialTLSConfig(config))
+	if *bindaddr != "" {
+		local, err := localAddr(*bindaddr)
 		if err != nil {
-			log.Fatal("Dial TLS ", err)
+			log.Fatal(err)
 		}
-		defer conn.Close()
+		netdialer.LocalAddr = local
+		netdialer.Timeout = *timeout
+		dialoptions = append(dialoptions, redis.DialNetDial(netdialer.Dial))
+	}
+
+	if *dryrun {
+		checkDryRun()
+	}
+
+	if *maxinflight < 1 {
+		log.Fatal("--max-inflight must be at least 1")
+	}
+
+	if *asserting && *commandsfile == "" {
+		log.Fatal("--assert checks the replies of a --commands-file, so needs one")
+	}
+
+	if *rawresp {
+		rawRESPSession()
+		os.Exit(0)
+	}
+
+	if *rdbfile != "" {
+		dumpRDB(*rdbfile)
+		os.Exit(0)
+	}
+
+	if *logfile != "" {
+		openTranscript(*logfile)
+	}
+
+	if *waitready > 0 {
+		conn, err = waitForReady(*waitready)
 	} else {
-		var err error
-		conn, err = redis.DialURL(connectionurl)
-		if err != nil {
-			log.Fatal("Dial ", err)
+		conn, err = dialWithRetry(*connectretry, *connectdelay)
+	}
+	if isAuthError(err) {
+		conn, err = authenticate(err)
+	}
+	if err == nil && tlsconfig == nil {
+		conn, err = checkPlaintext(conn, *autotls)
+	}
+	if err != nil {
+		log.Fatal("Dial ", err)
+	}
+	defer func() { conn.Close() }()
+
+	// The environment comes from the connection, so it is known before the
+	// one-shot and --cluster-call commands its production guard covers
+	setPromptValues(nil)
+	if err := setEnvironment(*envname, promptvalues["host"], *envcolors); err != nil {
+		log.Fatal(err)
+	}
+
+	if *certexpiry {
+		if tlsconfig == nil {
+			log.Fatal("--check-cert-expiry needs a TLS connection")
+		}
+		checkCertExpiry(*expirydays)
+	}
+
+	if *ping {
+		pingServer(*commandargs)
+	}
+
+	if *latencyalert > 0 {
+		latencyAlert(*latencyalert, *latencyexit)
+	}
+
+	if *notify {
+		watchKeyEvents(*redisdb, *enablenotify)
+	}
+
+	if *dump {
+		dumpKey(*commandargs)
+		os.Exit(0)
+	}
+
+	if *restore {
+		restoreKey(*commandargs, *replace)
+		os.Exit(0)
+	}
+
+	if *migrate != nil {
+		migrateKeys((*migrate).String(), *pattern, *replace)
+		os.Exit(0)
+	}
+
+	if *scanapply {
+		scanApply(*pattern, *applycommand, *yes)
+		os.Exit(0)
+	}
+
+	if *inspect != "" {
+		inspectKey(*inspect)
+		os.Exit(0)
+	}
+
+	if *exportfile != "" {
+		exportKeys(*exportfile, *pattern, *exportformat)
+		os.Exit(0)
+	}
+
+	if *countbytype {
+		countByType(*pattern)
+		os.Exit(0)
+	}
+
+	if *sample > 0 {
+		sampleKeys(*sample)
+		os.Exit(0)
+	}
+
+	if *estimatekeys {
+		estimateKeys(*samplesize)
+		os.Exit(0)
+	}
+
+	if *slowlog > 0 || *slowlogreset {
+		if *slowlog > 0 {
+			showSlowlog(*slowlog, *slowlogthresh)
+		}
+		if *slowlogreset {
+			resetSlowlog()
 		}
-		defer conn.Close()
+		os.Exit(0)
+	}
+
+	if *humaninfo {
+		showHumanInfo(*commandargs)
+		os.Exit(0)
+	}
+
+	if *hitratio {
+		showHitRatio(*hitthreshold)
+		os.Exit(0)
+	}
+
+	if *configdiff {
+		showConfigDiff()
+		os.Exit(0)
+	}
+
+	if *clients {
+		showClients()
+		os.Exit(0)
+	}
+
+	if *clustercall {
+		clusterCall(*commandargs)
+		os.Exit(0)
+	}
+
+	if *clusterdbsize {
+		clusterDBSize()
+		os.Exit(0)
+	}
+
+	if *evalfile != "" || *evalrofile != "" {
+		if *evalrofile != "" {
+			evalScript(*evalrofile, *commandargs, true)
+		} else {
+			evalScript(*evalfile, *commandargs, false)
+		}
+		os.Exit(0)
+	}
+
+	if *bench != "" {
+		benchmark(*bench, *benchclients, *requests, *randmax)
+		os.Exit(0)
+	}
+
+	if *lrutest > 0 {
+		lruTest(*lrutest)
+	}
+
+	if *commandsfile != "" {
+		os.Exit(runCommandsFile(*commandsfile, *stoponerror))
 	}
 
 	// We may not need to carry on setting up the interactive front end so...
 	if *commandargs != nil {
 		command := *commandargs
-		var args = make([]interface{}, len(command[1:]))
-		for i, d := range command[1:] {
-			args[i] = d
+		if err := checkCommand(command); err != nil {
+			kingpin.Fatalf("%s, try --help", err)
 		}
-		result, err := conn.Do(command[0], args...)
-
+		if *forceresp2 && switchesToRESP3(command) {
+			log.Fatal(errRESP3Refused)
+		}
+		if dryRunCommand(command) {
+			os.Exit(0)
+		}
+		if !confirmProduction(command) {
+			os.Exit(1)
+		}
+		args, err := fileArgs(command[1:])
 		if err != nil {
 			log.Fatal(err)
 		}
+		result, err := doRetrying(*retrytrans, command[0], args...)
+		logCommand(command, result, err)
 
-		switch v := result.(type) {
-		case redis.Error:
-			fmt.Printf("%s\n", v.Error())
-		case int64:
-			fmt.Printf("%d\n", v)
-		case string:
-			fmt.Printf("%s\n", v)
-		case []byte:
-			fmt.Printf("%s\n", string(v))
-		case nil:
-			fmt.Printf("nil\n")
-		case []interface{}:
-			for i, j := range v {
-				fmt.Printf("%d) %s\n", i+1, j)
-			}
+		if isShutdown(command) && shutdownSucceeded(err) {
+			fmt.Println("Server is shutting down")
+			os.Exit(0)
+		}
+
+		if rediserr, ok := err.(redis.Error); ok && isJSONFormat() {
+			printReply(command, rediserr)
+			os.Exit(1)
+		}
+
+		if hint := clusterHint(err); hint != "" {
+			log.Fatalf("%s\n%s", err, hint)
+		}
+		if isAuthError(err) {
+			log.Fatal(authHint(err))
+		}
+		if err != nil {
+			log.Fatal(err)
 		}
 
+		printReply(command, result)
+
 		os.Exit(0)
 	}
 
 	json.Unmarshal([]byte(redisCommandsJSON), &rawrediscommands)
 
-	rediscommands := make(map[string]Command, len(rawrediscommands))
+	rediscommands = make(map[string]Command, len(rawrediscommands))
 	commandstrings := make([]string, len(rawrediscommands))
 
 	i := 0
@@ -151,19 +501,52 @@ func main() {
 	sort.Strings(commandstrings)
 
 	reply, err := redis.String(conn.Do("INFO"))
+	if isAuthError(err) {
+		conn.Close()
+		if conn, err = authenticate(err); err == nil {
+			reply, err = redis.String(conn.Do("INFO"))
+		}
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	info := redisParseInfo(reply)
 
-	fmt.Printf("Connected to %s\n", info["redis_version"])
+	setPromptValues(info)
+
+	if err := setEnvironment(*envname, promptvalues["host"], *envcolors); err != nil {
+		log.Fatal(err)
+	}
+
+	if !isTerminal(os.Stdin) {
+		runOnConnect(nil, *onconnect)
+		status := runScript(os.Stdin, *stoponerror)
+		if *profile {
+			printProfile()
+		}
+		os.Exit(status)
+	}
+
+	if !*quiet {
+		fmt.Printf("Connected to %s\n", info["redis_version"])
+	}
 
 	liner := liner.NewLiner()
 	defer liner.Close()
 
 	liner.SetCtrlCAborts(true)
 
+	historypath := ""
+	if !*nohistory {
+		historypath, err = historyPath(promptvalues["host"], promptvalues["port"], *perhosthist)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Can't keep history: %s\n", err)
+		} else {
+			loadHistory(liner, historypath)
+		}
+	}
+
 	liner.SetCompleter(func(line string) (c []string) {
 		lowerline := strings.ToLower(line)
 		for _, n := range commandstrings {
@@ -184,8 +567,25 @@ func main() {
 		return
 	})
 
+	runOnConnect(liner, *onconnect)
+
+	idle := newIdleTimer(*idletimeout, func() {
+		fmt.Printf("\nNo input for %v, disconnecting\n", *idletimeout)
+		if historypath != "" {
+			saveHistory(liner, historypath)
+		}
+		liner.Close()
+		conn.Close()
+		os.Exit(0)
+	})
+
 	for {
-		line, err := liner.Prompt(getPrompt())
+		idle.waiting()
+		line, err := readLine(liner)
+		idle.active()
+		if promptAborted(err) {
+			continue
+		}
 		if err != nil {
 			break
 		}
@@ -194,69 +594,96 @@ func main() {
 			continue // Ignore no input
 		}
 
-		parts, err := shellwords.Parse(line)
+		commands, err := splitCommands(line)
+		if err != nil {
+			fmt.Printf("Can't parse command: %s\n", err)
+			continue
+		}
 
-		if len(parts) == 0 {
+		if len(commands) == 0 {
 			continue // Ignore no input
 		}
 
-		liner.AppendHistory(line)
-
-		if parts[0] == "help" {
-			if len(parts) == 1 {
-				fmt.Println("Enter help <command> to show information about a command")
-				continue
-			}
-			lookup := parts[1]
-			if len(parts) == 3 {
-				lookup = parts[1] + " " + parts[2]
-			}
-			commanddata, ok := rediscommands[lookup]
-			if ok {
-				fmt.Printf("Command: %s\n", strings.ToUpper(lookup))
-				fmt.Printf("Summary: %s\n", commanddata.Summary)
-				if commanddata.Complexity != "" {
-					fmt.Printf("Complexity: %s\n", commanddata.Complexity)
-				}
-				if commanddata.Arguments != nil {
-					fmt.Println("Args:")
-					for _, a := range commanddata.Arguments {
-						fmt.Printf("     %s (%s)\n", a.Name, a.Type)
-					}
-				}
-				continue
-			}
+		addHistory(liner, line)
+		echoLine(line)
 
+		if carryon, _ := runCommands(liner, commands); !carryon {
+			break
 		}
+	}
 
-		if parts[0] == "exit" {
-			break
+	if historypath != "" {
+		saveHistory(liner, historypath)
+	}
+
+	if *profile {
+		printProfile()
+	}
+}
+
+// dial opens a new connection using the settings worked out at startup
+func dial() (redis.Conn, error) {
+	return dialURL(connectionurl)
+}
+
+// dialURL connects to a server with the settings worked out at startup
+func dialURL(rawurl string) (redis.Conn, error) {
+	return dialAs(rawurl, authuser, authpassword)
+}
+
+// dialAs connects to a server with the settings worked out at startup,
+// sending AUTH for the ACL user given when it isn't empty
+func dialAs(rawurl string, user string, password string) (redis.Conn, error) {
+	newconn, err := redis.DialURL(rawurl, dialoptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	if user != "" {
+		if _, err := newconn.Do("AUTH", user, password); err != nil {
+			newconn.Close()
+			return nil, err
 		}
+	}
 
-		var args = make([]interface{}, len(parts[1:]))
-		for i, d := range parts[1:] {
-			args[i] = d
+	if *forceresp2 {
+		if err := forceRESP2(newconn); err != nil {
+			newconn.Close()
+			return nil, err
 		}
+	}
+	return newconn, nil
+}
 
-		result, err := conn.Do(parts[0], args...)
+// interfaceArgs converts command arguments for passing to Do
+func interfaceArgs(parts []string) []interface{} {
+	args := make([]interface{}, len(parts))
+	for i, d := range parts {
+		args[i] = d
+	}
+	return args
+}
 
-		switch v := result.(type) {
-		case redis.Error:
-			fmt.Printf("%s\n", v.Error())
-		case int64:
-			fmt.Printf("%d\n", v)
-		case string:
-			fmt.Printf("%s\n", v)
-		case []byte:
-			fmt.Printf("%s\n", string(v))
-		case nil:
-			fmt.Printf("nil\n")
-		case []interface{}:
-			for i, j := range v {
-				fmt.Printf("%d) %s\n", i+1, j)
-			}
-		}
+// errEmptyCommand is returned for a one-shot command with no name
+var errEmptyCommand = errors.New("empty command")
+
+// checkCommand rejects a one-shot command with no command name to send
+func checkCommand(command []string) error {
+	if len(command) == 0 || strings.TrimSpace(command[0]) == "" {
+		return errEmptyCommand
+	}
+	return nil
+}
+
+// dialWithRetry calls dial, retrying up to retries times on failure
+func dialWithRetry(retries int, delay time.Duration) (redis.Conn, error) {
+	newconn, err := dial()
+	for attempt := 1; err != nil && attempt <= retries; attempt++ {
+		fmt.Fprintf(os.Stderr, "Dial failed: %s, retrying in %v (%d/%d)\n", err, delay, attempt, retries)
+		time.Sleep(delay)
+		newconn, err = dial()
 	}
+	return newconn, err
 }
 
 func redisParseInfo(reply string) map[string]string {
@@ -273,12 +700,32 @@ func redisParseInfo(reply string) map[string]string {
 	return values
 }
 
-func getPrompt() string {
-	if *longprompt {
-		return fmt.Sprintf("%s:%s> ", (*redisurl).Hostname(), (*redisurl).Port())
-	}
+// infoSection is one "# Name" section of an INFO reply, with its fields in
+// the order the server gave them
+type infoSection struct {
+	name   string
+	fields [][2]string
+}
 
-	return "> "
+// redisParseInfoSections splits an INFO reply into its sections
+func redisParseInfoSections(reply string) []infoSection {
+	sections := []infoSection{}
+	for _, line := range strings.Split(reply, "\r\n") {
+		if strings.HasPrefix(line, "#") {
+			sections = append(sections, infoSection{name: strings.TrimSpace(strings.TrimPrefix(line, "#"))})
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if len(sections) == 0 {
+			sections = append(sections, infoSection{})
+		}
+		last := &sections[len(sections)-1]
+		last.fields = append(last.fields, [2]string{parts[0], parts[1]})
+	}
+	return sections
 }
 
 func printAsJSON(toprint interface{}) {
@@ -304,4 +751,5 @@ type Argument struct {
 	Type     string `json:"type"`
 	Enum     string `json:"enum,omitempty"`
 	Optional bool   `json:"optional"`
+	Multiple bool   `json:"multiple"`
 }
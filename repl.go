@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/mattn/go-shellwords"
+	"github.com/peterh/liner"
+)
+
+// splitCommands splits an input line into commands at semicolons which
+// aren't quoted or escaped, then parses each command into its arguments
+func splitCommands(input string) ([][]string, error) {
+	// redis-cli has no semicolons or braces, so with its quoting a line is
+	// one command taken as it is
+	if *cliquoting {
+		parts, err := splitArgs(input)
+		if err != nil || len(parts) == 0 {
+			return nil, err
+		}
+		return [][]string{parts}, nil
+	}
+
+	segments := []string{}
+	var escaped, singlequoted, doublequoted bool
+	segmentstart := 0
+	for i, r := range input {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && !singlequoted:
+			escaped = true
+		case r == '\'' && !doublequoted:
+			singlequoted = !singlequoted
+		case r == '"' && !singlequoted:
+			doublequoted = !doublequoted
+		case r == ';' && !singlequoted && !doublequoted:
+			segments = append(segments, input[segmentstart:i])
+			segmentstart = i + 1
+		}
+	}
+	segments = append(segments, input[segmentstart:])
+
+	commands := [][]string{}
+	for _, segment := range segments {
+		expanded, err := expandBraces(segment)
+		if err != nil {
+			return nil, err
+		}
+		parts, err := shellwords.Parse(expanded)
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) > 0 {
+			commands = append(commands, parts)
+		}
+	}
+	return commands, nil
+}
+
+// echoLine prints a command line as entered, when --echo or :echo is on,
+// so transcripts show what each reply was for
+func echoLine(input string) {
+	if *echo {
+		fmt.Println("> " + input)
+	}
+}
+
+// runCommands runs each command in turn, returning false if the session
+// should end, along with the error of the last command to fail
+func runCommands(line *liner.State, commands [][]string) (bool, error) {
+	var failure error
+	for _, parts := range commands {
+		carryon, err := runCommand(line, parts)
+		if err != nil {
+			failure = err
+		}
+		if !carryon {
+			return false, failure
+		}
+	}
+	return true, failure
+}
+
+// runCommand runs one command entered in the REPL, whether that is help, a
+// meta command or a command for the server, and prints the result. It
+// returns false if the session should end, and the error if the command
+// failed.
+func runCommand(line *liner.State, parts []string) (bool, error) {
+	parts, err := expandAlias(parts)
+	if err == nil {
+		parts, err = expandHelper(parts)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return true, err
+	}
+
+	if parts[0] == "help" {
+		if len(parts) == 1 {
+			fmt.Println("Enter help <command> to show information about a command")
+			return true, nil
+		}
+		lookup := parts[1]
+		if len(parts) == 3 {
+			lookup = parts[1] + " " + parts[2]
+		}
+		commanddata, ok := rediscommands[lookup]
+		if ok {
+			fmt.Printf("Command: %s\n", strings.ToUpper(lookup))
+			fmt.Printf("Summary: %s\n", commanddata.Summary)
+			if commanddata.Complexity != "" {
+				fmt.Printf("Complexity: %s\n", commanddata.Complexity)
+			}
+			if commanddata.Arguments != nil {
+				fmt.Println("Args:")
+				for _, a := range commanddata.Arguments {
+					fmt.Printf("     %s (%s)\n", a.Name, a.Type)
+				}
+			}
+			return true, nil
+		}
+
+	}
+
+	if parts[0] == "exit" {
+		return false, nil
+	}
+
+	if runMetaCommand(parts) {
+		return true, nil
+	}
+
+	if dryRunCommand(parts) {
+		return true, nil
+	}
+
+	if isShutdown(parts) {
+		if !confirm(line, "Really shut down the server?") {
+			return true, nil
+		}
+	} else if isProduction() && isDangerousCommand(parts) {
+		if !confirm(line, fmt.Sprintf("Really run %s against %s?", strings.ToUpper(parts[0]), environment)) {
+			return true, nil
+		}
+	}
+
+	if *forceresp2 && switchesToRESP3(parts) {
+		fmt.Println(errRESP3Refused)
+		return true, errRESP3Refused
+	}
+
+	args, err := fileArgs(parts[1:])
+	if err != nil {
+		fmt.Println(err)
+		return true, err
+	}
+
+	if queueCommand(parts, args) {
+		return true, nil
+	}
+
+	idle := keyIdleTime(parts)
+
+	start := time.Now()
+
+	var result interface{}
+	if isBlockingCommand(parts) {
+		result, err = doInterruptible(parts[0], args...)
+		if err == errInterrupted {
+			fmt.Println("Interrupted")
+			return true, nil
+		}
+	} else {
+		result, err = doRetrying(*retrytrans, parts[0], args...)
+		if isBusyError(err) && line != nil && confirm(line, fmt.Sprintf("%s\nKill the script with SCRIPT KILL and rerun %s?", err, strings.ToUpper(parts[0]))) {
+			if _, killerr := conn.Do("SCRIPT", "KILL"); killerr != nil {
+				fmt.Println(killerr)
+			} else {
+				result, err = doRetrying(*retrytrans, parts[0], args...)
+			}
+		}
+	}
+
+	logCommand(parts, result, err)
+	trackSession(parts, err)
+
+	if isShutdown(parts) && shutdownSucceeded(err) {
+		fmt.Println("Server is shutting down")
+		return false, nil
+	}
+
+	if err != nil {
+		if rediserr, ok := err.(redis.Error); ok {
+			result = rediserr
+		} else {
+			fmt.Println(err)
+			return true, err
+		}
+	}
+
+	if *profile {
+		recordLatency(parts[0], time.Since(start))
+		if err != nil {
+			fmt.Println(err)
+		}
+		return true, err
+	}
+
+	if quietreplies && err == nil {
+		return true, nil
+	}
+
+	printReply(parts, result)
+	if idle != "" {
+		fmt.Println(idle)
+	}
+	if hint := clusterHint(err); hint != "" {
+		fmt.Println(hint)
+		if followed, ferr := followMoved(line, parts, err); followed {
+			return true, ferr
+		}
+	}
+	return true, err
+}
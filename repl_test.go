@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandsUnbalancedQuotes(t *testing.T) {
+	lines := []string{
+		`SET a "unterminated`,
+		`SET a 'unterminated`,
+		`GET "a" ; SET b "c`,
+		`SET a "b'`,
+	}
+	for _, cliquoted := range []bool{false, true} {
+		setFlag(t, cliquoting, cliquoted)
+		for _, line := range lines {
+			if commands, err := splitCommands(line); err == nil {
+				t.Errorf("splitCommands(%q) with redis-cli quoting %v = %q, want an error", line, cliquoted, commands)
+			}
+		}
+	}
+}
+
+func TestSplitCommands(t *testing.T) {
+	tests := []struct {
+		line string
+		want [][]string
+	}{
+		{"PING", [][]string{{"PING"}}},
+		{"GET key", [][]string{{"GET", "key"}}},
+		{`SET key "a b"`, [][]string{{"SET", "key", "a b"}}},
+		{"SET a 1; GET a", [][]string{{"SET", "a", "1"}, {"GET", "a"}}},
+		{`SET a "x;y"`, [][]string{{"SET", "a", "x;y"}}},
+		{"", [][]string{}},
+		{"   ", [][]string{}},
+	}
+	setFlag(t, cliquoting, false)
+	for _, test := range tests {
+		got, err := splitCommands(test.line)
+		if err != nil {
+			t.Errorf("splitCommands(%q): %s", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitCommands(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	tests := []struct {
+		command []string
+		ok      bool
+	}{
+		{[]string{"PING"}, true},
+		{[]string{"GET", "key"}, true},
+		{[]string{""}, false},
+		{[]string{"  ", "key"}, false},
+		{[]string{}, false},
+	}
+	for _, test := range tests {
+		err := checkCommand(test.command)
+		if (err == nil) != test.ok {
+			t.Errorf("checkCommand(%q) = %v, want ok %v", test.command, err, test.ok)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// errRESP3Refused is returned for HELLO 3 when --force-resp2 is given
+var errRESP3Refused = errors.New("HELLO 3 refused, --force-resp2 keeps the connection on RESP2")
+
+// forceRESP2 asks the server to use RESP2, the only protocol redigo parses,
+// on a new connection. Servers older than Redis 6 don't have HELLO, but only
+// speak RESP2 anyway.
+func forceRESP2(c redis.Conn) error {
+	_, err := c.Do("HELLO", "2")
+	if rediserr, ok := err.(redis.Error); ok && strings.HasPrefix(rediserr.Error(), "ERR unknown command") {
+		return nil
+	}
+	return err
+}
+
+// switchesToRESP3 reports whether a command would switch the connection to
+// RESP3
+func switchesToRESP3(parts []string) bool {
+	return strings.ToUpper(parts[0]) == "HELLO" && len(parts) > 1 && parts[1] == "3"
+}
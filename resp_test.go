@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSwitchesToRESP3(t *testing.T) {
+	tests := []struct {
+		command []string
+		want    bool
+	}{
+		{[]string{"HELLO", "3"}, true},
+		{[]string{"hello", "3", "AUTH", "user", "secret"}, true},
+		{[]string{"HELLO", "2"}, false},
+		{[]string{"HELLO"}, false},
+		{[]string{"GET", "3"}, false},
+	}
+	for _, test := range tests {
+		if got := switchesToRESP3(test.command); got != test.want {
+			t.Errorf("switchesToRESP3(%q) = %v, want %v", test.command, got, test.want)
+		}
+	}
+}
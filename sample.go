@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/mattn/go-runewidth"
+)
+
+// previewLength is the longest value preview shown by --sample
+const previewLength = 60
+
+// previewItems is how many elements of a collection are previewed
+const previewItems = 5
+
+// sampleKeys prints up to n distinct random keys with their type and a short
+// preview of their value
+func sampleKeys(n int) {
+	seen := map[string]bool{}
+	for attempts := 0; len(seen) < n && attempts < n*10; attempts++ {
+		key, err := redis.String(conn.Do("RANDOMKEY"))
+		if err == redis.ErrNil {
+			fmt.Println("Database is empty")
+			return
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		keytype, err := redis.String(conn.Do("TYPE", key))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("%s (%s) %s\n", key, keytype, previewKey(key, keytype))
+	}
+}
+
+// previewKey returns the start of a key's value, truncated for display
+func previewKey(key string, keytype string) string {
+	var values []string
+	var err error
+
+	switch keytype {
+	case "string":
+		var value string
+		value, err = redis.String(conn.Do("GETRANGE", key, 0, previewLength))
+		values = []string{value}
+	case "list":
+		values, err = redis.Strings(conn.Do("LRANGE", key, 0, previewItems-1))
+	case "set":
+		values, err = redis.Strings(conn.Do("SRANDMEMBER", key, previewItems))
+	case "zset":
+		values, err = redis.Strings(conn.Do("ZRANGE", key, 0, previewItems-1))
+	case "hash":
+		var reply []interface{}
+		reply, err = redis.Values(conn.Do("HSCAN", key, 0, "COUNT", previewItems))
+		if err == nil {
+			values, err = redis.Strings(reply[1], nil)
+		}
+	default:
+		return ""
+	}
+
+	if err != nil {
+		return err.Error()
+	}
+
+	return truncate(strings.Join(values, ", "), previewLength)
+}
+
+// truncate shortens s to at most n columns wide, ending with … where it was
+// cut
+func truncate(s string, n int) string {
+	return runewidth.Truncate(s, n, "…")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// scanCount is the COUNT hint passed to SCAN
+const scanCount = 1000
+
+// scanKeys walks the keyspace with SCAN from cursor start, calling fn for
+// every key matching pattern. It stops at the first error returned by SCAN
+// or fn. A limit above zero stops the walk after that many keys, printing
+// the cursor to continue from; keys from that SCAN call which were already
+// seen will be seen again when continuing, rather than any being missed.
+func scanKeys(pattern string, start string, limit int, fn func(key string) error) error {
+	cursor := start
+	seen := 0
+	for {
+		values, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", pattern, "COUNT", scanCount))
+		if err != nil {
+			return err
+		}
+
+		next, err := redis.String(values[0], nil)
+		if err != nil {
+			return err
+		}
+
+		keys, err := redis.Strings(values[1], nil)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if limit > 0 && seen == limit {
+				fmt.Printf("(limit reached, continue with --cursor %s)\n", cursor)
+				return nil
+			}
+			if err := fn(key); err != nil {
+				return err
+			}
+			seen++
+		}
+
+		cursor = next
+		if cursor == "0" {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/mattn/go-shellwords"
+	"github.com/peterh/liner"
+)
+
+// readonlycommands only read the key they are given, and so can be run by
+// --scan-apply without confirming. Anything else, including module commands,
+// may change data on every matching key.
+var readonlycommands = map[string]bool{
+	"exists":               true,
+	"type":                 true,
+	"ttl":                  true,
+	"pttl":                 true,
+	"expiretime":           true,
+	"pexpiretime":          true,
+	"object":               true,
+	"dump":                 true,
+	"touch":                true,
+	"get":                  true,
+	"getrange":             true,
+	"substr":               true,
+	"strlen":               true,
+	"mget":                 true,
+	"lcs":                  true,
+	"getbit":               true,
+	"bitcount":             true,
+	"bitpos":               true,
+	"bitfield_ro":          true,
+	"hget":                 true,
+	"hmget":                true,
+	"hgetall":              true,
+	"hkeys":                true,
+	"hvals":                true,
+	"hlen":                 true,
+	"hexists":              true,
+	"hstrlen":              true,
+	"hrandfield":           true,
+	"hscan":                true,
+	"httl":                 true,
+	"hpttl":                true,
+	"hexpiretime":          true,
+	"hpexpiretime":         true,
+	"lrange":               true,
+	"lindex":               true,
+	"llen":                 true,
+	"lpos":                 true,
+	"smembers":             true,
+	"sismember":            true,
+	"smismember":           true,
+	"scard":                true,
+	"srandmember":          true,
+	"sscan":                true,
+	"sinter":               true,
+	"sunion":               true,
+	"sdiff":                true,
+	"sintercard":           true,
+	"zrange":               true,
+	"zrangebyscore":        true,
+	"zrangebylex":          true,
+	"zrevrange":            true,
+	"zrevrangebyscore":     true,
+	"zrevrangebylex":       true,
+	"zscore":               true,
+	"zmscore":              true,
+	"zrank":                true,
+	"zrevrank":             true,
+	"zcard":                true,
+	"zcount":               true,
+	"zlexcount":            true,
+	"zscan":                true,
+	"zrandmember":          true,
+	"zinter":               true,
+	"zunion":               true,
+	"zdiff":                true,
+	"zintercard":           true,
+	"pfcount":              true,
+	"geopos":               true,
+	"geodist":              true,
+	"geohash":              true,
+	"geosearch":            true,
+	"georadius_ro":         true,
+	"georadiusbymember_ro": true,
+	"xrange":               true,
+	"xrevrange":            true,
+	"xlen":                 true,
+	"xinfo":                true,
+	"xpending":             true,
+}
+
+// needsConfirming reports whether --scan-apply should ask before running a
+// command template on every key, which it does for all but the
+// readonlycommands and MEMORY USAGE
+func needsConfirming(parts []string) bool {
+	name := strings.ToLower(parts[0])
+	if name == "memory" {
+		return len(parts) < 2 || strings.ToLower(parts[1]) != "usage"
+	}
+	return !readonlycommands[name]
+}
+
+// scanApply runs a command template on every key matching pattern, with {}
+// in its arguments replaced by the key. Commands are pipelined on a
+// connection of their own, so they don't disturb the SCAN.
+func scanApply(pattern string, template string, yes bool) {
+	parts, err := shellwords.Parse(template)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(parts) == 0 {
+		log.Fatal("No command given with --command")
+	}
+
+	if !yes && !*dryrun && needsConfirming(parts) {
+		if !isTerminal(os.Stdin) {
+			log.Fatalf("%s may change every key matching %s, give --yes to run it without a terminal to confirm on", quoteCommand(parts), pattern)
+		}
+		line := liner.NewLiner()
+		ok := confirm(line, fmt.Sprintf("Really run %s on every key matching %s?", quoteCommand(parts), pattern))
+		line.Close()
+		if !ok {
+			os.Exit(1)
+		}
+	}
+
+	applyconn, err := dial()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer applyconn.Close()
+
+	applied, failed := 0, 0
+	window := newPipelineWindow(applyconn, func(_ interface{}, err error) error {
+		if rediserr, ok := err.(redis.Error); ok {
+			if failed == 0 {
+				fmt.Printf("First error: %s\n", rediserr)
+			}
+			failed++
+		} else if err != nil {
+			return err
+		} else {
+			applied++
+		}
+		return nil
+	})
+
+	err = scanKeys(pattern, *scancursor, *scanlimit, func(key string) error {
+		command := make([]string, len(parts))
+		for i, part := range parts {
+			command[i] = strings.Replace(part, "{}", key, -1)
+		}
+		if dryRunCommand(command) {
+			applied++
+			return nil
+		}
+		return window.send(command[0], interfaceArgs(command[1:])...)
+	})
+	if err == nil {
+		err = window.drain()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *dryrun {
+		fmt.Printf("Would apply %s to %d keys\n", quoteCommand(parts), applied)
+		return
+	}
+	fmt.Printf("Applied %s to %d keys", quoteCommand(parts), applied)
+	if failed > 0 {
+		fmt.Printf(", %d failed", failed)
+	}
+	fmt.Println()
+}
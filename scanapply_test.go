@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNeedsConfirming(t *testing.T) {
+	tests := []struct {
+		template []string
+		want     bool
+	}{
+		{[]string{"DEL", "{}"}, true},
+		{[]string{"expire", "{}", "3600"}, true},
+		{[]string{"SET", "{}", "value"}, true},
+		{[]string{"HDEL", "{}", "field"}, true},
+		{[]string{"LTRIM", "{}", "0", "99"}, true},
+		{[]string{"XTRIM", "{}", "MAXLEN", "1000"}, true},
+		{[]string{"EVAL", "return redis.call('DEL', KEYS[1])", "1", "{}"}, true},
+		{[]string{"EVALSHA", "abc", "1", "{}"}, true},
+		{[]string{"FCALL", "cleanup", "1", "{}"}, true},
+		{[]string{"COPY", "{}", "backup", "REPLACE"}, true},
+		{[]string{"SORT", "{}", "STORE", "{}"}, true},
+		{[]string{"SUNIONSTORE", "{}", "{}", "other"}, true},
+		{[]string{"ZUNIONSTORE", "{}", "2", "{}", "other"}, true},
+		{[]string{"GEOSEARCHSTORE", "{}", "{}", "FROMLONLAT", "0", "0", "BYRADIUS", "1", "km"}, true},
+		{[]string{"BITOP", "AND", "{}", "{}", "other"}, true},
+		{[]string{"PFMERGE", "{}", "other"}, true},
+		{[]string{"SETBIT", "{}", "7", "1"}, true},
+		{[]string{"HINCRBY", "{}", "n", "1"}, true},
+		{[]string{"JSON.DEL", "{}"}, true},
+		{[]string{"MEMORY", "PURGE"}, true},
+		{[]string{"FLUSHDB"}, true},
+		{[]string{"TTL", "{}"}, false},
+		{[]string{"type", "{}"}, false},
+		{[]string{"OBJECT", "ENCODING", "{}"}, false},
+		{[]string{"HGETALL", "{}"}, false},
+		{[]string{"memory", "usage", "{}"}, false},
+	}
+	for _, test := range tests {
+		if got := needsConfirming(test.template); got != test.want {
+			t.Errorf("needsConfirming(%q) = %v, want %v", test.template, got, test.want)
+		}
+	}
+}
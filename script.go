@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+// runScript runs the commands in r, one or more to a line, as if they had
+// been typed at the prompt. It returns the exit status: 1 if it stopped at
+// a failing command because of stoponerror, otherwise 0.
+func runScript(r io.Reader, stoponerror bool) int {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 512*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		commands, err := splitCommands(scanner.Text())
+		if err != nil {
+			fmt.Printf("Can't parse command on line %d: %s\n", n, err)
+			if stoponerror {
+				return 1
+			}
+			continue
+		}
+
+		echoLine(scanner.Text())
+		carryon, err := runCommands(nil, commands)
+		if err != nil && stoponerror {
+			fmt.Fprintf(os.Stderr, "Stopping at line %d\n", n)
+			return 1
+		}
+		if !carryon {
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	return 0
+}
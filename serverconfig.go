@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// getServerConfig returns the value of one of the server's settings, as
+// read with CONFIG GET. Modes use it to check the server is set up for
+// them, so they can explain what's wrong rather than fail obscurely.
+func getServerConfig(name string) (string, error) {
+	values, err := redis.StringMap(conn.Do("CONFIG", "GET", name))
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[name]
+	if !ok {
+		return "", fmt.Errorf("server has no %s setting", name)
+	}
+	return value, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// session holds what commands have changed about the connection since it
+// was dialled, so that a replacement connection can be set up the same way
+var session struct {
+	db   string // database chosen with SELECT, "" for the one in the URI
+	name string // name given with CLIENT SETNAME
+}
+
+// trackSession records a SELECT or CLIENT SETNAME which succeeded
+func trackSession(parts []string, err error) {
+	if err != nil || len(parts) < 2 {
+		return
+	}
+	switch strings.ToLower(parts[0]) {
+	case "select":
+		session.db = parts[1]
+	case "client":
+		if strings.ToLower(parts[1]) == "setname" && len(parts) == 3 {
+			session.name = parts[2]
+		}
+	}
+}
+
+// resetSession forgets the session's changes, for a connection to another
+// server
+func resetSession() {
+	session.db, session.name = "", ""
+}
+
+// restoreSession selects the database and sets the client name the session
+// had on a connection dialled to replace its old one
+func restoreSession(c redis.Conn) error {
+	if session.db != "" {
+		if _, err := c.Do("SELECT", session.db); err != nil {
+			return err
+		}
+	}
+	if session.name != "" {
+		if _, err := c.Do("CLIENT", "SETNAME", session.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// warnSessionReset tells the user when a new connection couldn't be put back
+// as the old one was, and forgets the changes which were lost
+func warnSessionReset(err error) {
+	fmt.Fprintf(os.Stderr, "Database and client name were reset on reconnecting: %s\n", err)
+	resetSession()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSessionRestoredOnReconnect(t *testing.T) {
+	blank := session
+	blank.db, blank.name = "", ""
+	setFlag(t, &session, blank)
+
+	trackSession([]string{"SELECT", "3"}, nil)
+	trackSession([]string{"client", "setname", "worker"}, nil)
+	trackSession([]string{"SELECT", "99"}, errors.New("ERR DB index is out of range"))
+	trackSession([]string{"GET", "key"}, nil)
+
+	recording := useConn(t, map[string]interface{}{})
+	if err := restoreSession(recording); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"SELECT", "3"}, {"CLIENT", "SETNAME", "worker"}}
+	if !reflect.DeepEqual(recording.commands, want) {
+		t.Errorf("restoreSession sent %q, want %q", recording.commands, want)
+	}
+
+	resetSession()
+	recording.commands = nil
+	if err := restoreSession(recording); err != nil {
+		t.Fatal(err)
+	}
+	if len(recording.commands) != 0 {
+		t.Errorf("restoreSession after resetSession sent %q, want nothing", recording.commands)
+	}
+}
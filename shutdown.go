@@ -0,0 +1,18 @@
+package main
+
+import (
+	"io"
+	"strings"
+)
+
+// isShutdown reports whether a command is SHUTDOWN
+func isShutdown(parts []string) bool {
+	return strings.ToLower(parts[0]) == "shutdown"
+}
+
+// shutdownSucceeded reports whether the error from sending SHUTDOWN means
+// it worked. A server which shuts down closes the connection rather than
+// replying, so the read fails with EOF.
+func shutdownSucceeded(err error) bool {
+	return err == io.EOF || err == io.ErrUnexpectedEOF
+}
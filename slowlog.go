@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/mattn/go-runewidth"
+)
+
+// showSlowlog prints the last count SLOWLOG entries, highlighting those
+// which took longer than threshold
+func showSlowlog(count int, threshold time.Duration) {
+	entries, err := redis.Values(conn.Do("SLOWLOG", "GET", count))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("Slowlog is empty")
+		return
+	}
+
+	color := useColor()
+
+	for _, entry := range entries {
+		fields, err := redis.Values(entry, nil)
+		if err != nil || len(fields) < 4 {
+			log.Fatal("Unexpected slowlog entry")
+		}
+
+		id, _ := redis.Int64(fields[0], nil)
+		timestamp, _ := redis.Int64(fields[1], nil)
+		micros, _ := redis.Int64(fields[2], nil)
+		args, _ := redis.Strings(fields[3], nil)
+
+		client := ""
+		if len(fields) >= 6 {
+			addr, _ := redis.String(fields[4], nil)
+			name, _ := redis.String(fields[5], nil)
+			client = addr
+			if name != "" {
+				client = client + " (" + name + ")"
+			}
+		}
+
+		when := time.Unix(timestamp, 0)
+		duration := time.Duration(micros) * time.Microsecond
+
+		line := fmt.Sprintf("#%-6d %s %10v  %s  %s", id,
+			runewidth.FillRight(formatTime(when), 25), duration, quoteCommand(args), client)
+
+		if color && duration > threshold {
+			line = "\x1b[31m" + line + "\x1b[0m"
+		}
+		fmt.Println(line)
+	}
+}
+
+// resetSlowlog empties the server's slowlog
+func resetSlowlog() {
+	if _, err := conn.Do("SLOWLOG", "RESET"); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Slowlog reset")
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// sortedReply sorts the elements of array replies for --sort and
+// --sort-numeric. Only arrays of plain values are sorted, so nested replies
+// such as SCAN's cursor and keys keep their shape.
+func sortedReply(reply interface{}) interface{} {
+	if !*sortreplies && !*sortnumeric {
+		return reply
+	}
+	return sortValues(reply)
+}
+
+// sortValues returns a sorted copy of an array reply and any arrays in it
+func sortValues(reply interface{}) interface{} {
+	values, ok := reply.([]interface{})
+	if !ok {
+		return reply
+	}
+
+	sorted := make([]interface{}, len(values))
+	plain := true
+	for i, value := range values {
+		if _, nested := value.([]interface{}); nested {
+			plain = false
+		}
+		sorted[i] = sortValues(value)
+	}
+	if plain {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return lessValue(sorted[i], sorted[j])
+		})
+	}
+	return sorted
+}
+
+// lessValue orders two elements of a reply by their text or, with
+// --sort-numeric, as numbers, putting anything which isn't a number last
+func lessValue(a interface{}, b interface{}) bool {
+	atext, btext := valueText(a), valueText(b)
+	if *sortnumeric {
+		anum, aerr := strconv.ParseFloat(atext, 64)
+		bnum, berr := strconv.ParseFloat(btext, 64)
+		switch {
+		case aerr == nil && berr == nil && anum != bnum:
+			return anum < bnum
+		case aerr == nil && berr != nil:
+			return true
+		case aerr != nil && berr == nil:
+			return false
+		}
+	}
+	return atext < btext
+}
+
+// valueText returns the text a plain reply value is sorted by
+func valueText(value interface{}) string {
+	switch value := value.(type) {
+	case []byte:
+		return string(value)
+	case string:
+		return value
+	case double:
+		return string(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case redis.Error:
+		return value.Error()
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
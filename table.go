@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// formatTable lays out rows under headers in left aligned columns. Columns
+// are sized by display width, so wide characters such as CJK and emoji
+// still line up.
+func formatTable(headers []string, rows [][]string) string {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = runewidth.StringWidth(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) && runewidth.StringWidth(cell) > widths[i] {
+				widths[i] = runewidth.StringWidth(cell)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	writeRow := func(row []string) {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = runewidth.FillRight(cell, widths[i])
+		}
+		buf.WriteString(strings.TrimRight(strings.Join(cells, "  "), " "))
+		buf.WriteString("\n")
+	}
+
+	writeRow(headers)
+	for _, row := range rows {
+		writeRow(row)
+	}
+	return buf.String()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestFormatTable(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		rows    [][]string
+		want    string
+	}{
+		{
+			"ascii",
+			[]string{"key", "type"},
+			[][]string{{"a", "string"}, {"longer", "hash"}},
+			"key     type\n" +
+				"a       string\n" +
+				"longer  hash\n",
+		},
+		{
+			"multibyte",
+			[]string{"key", "type"},
+			[][]string{{"café", "set"}, {"ab", "list"}},
+			"key   type\n" +
+				"café  set\n" +
+				"ab    list\n",
+		},
+		{
+			"double width",
+			[]string{"key", "type"},
+			[][]string{{"日本", "zset"}, {"a", "hash"}},
+			"key   type\n" +
+				"日本  zset\n" +
+				"a     hash\n",
+		},
+		{
+			"emoji",
+			[]string{"name", "n"},
+			[][]string{{"🍕🍕", "1"}, {"x", "22"}},
+			"name  n\n" +
+				"🍕🍕  1\n" +
+				"x     22\n",
+		},
+	}
+	for _, test := range tests {
+		if got := formatTable(test.headers, test.rows); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"truncated", 5, "trun…"},
+		{"cafés", 4, "caf…"},
+		{"日本語テキスト", 5, "日本…"},
+		{"日本語テキスト", 6, "日本…"},
+		{"日本語", 6, "日本語"},
+		{"🍕🍕🍕", 3, "🍕…"},
+	}
+	for _, test := range tests {
+		got := truncate(test.s, test.n)
+		if got != test.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", test.s, test.n, got, test.want)
+		}
+		if width := runewidth.StringWidth(got); width > test.n {
+			t.Errorf("truncate(%q, %d) is %d columns wide", test.s, test.n, width)
+		}
+	}
+}
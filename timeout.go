@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// doWithTimeout runs a command, giving up on its reply after
+// --command-timeout when one is set. A connection which timed out may still
+// get the reply later, so it is replaced with a fresh one.
+func doWithTimeout(command string, args ...interface{}) (interface{}, error) {
+	if *cmdtimeout <= 0 {
+		return conn.Do(command, args...)
+	}
+
+	result, err := redis.DoWithTimeout(conn, *cmdtimeout, command, args...)
+	if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+		reconnect()
+		return nil, fmt.Errorf("no reply within %v", *cmdtimeout)
+	}
+	return result, err
+}
+
+// reconnect replaces a connection which can no longer be trusted to match
+// replies to commands with a fresh one, on the same database and with the
+// same client name
+func reconnect() {
+	conn.Close()
+	newconn, err := dial()
+	if err != nil {
+		log.Fatal("Reconnect ", err)
+	}
+	if err := restoreSession(newconn); err != nil {
+		warnSessionReset(err)
+	}
+	conn = newconn
+}
+
+// timeoutCommand implements :timeout, which shows or sets how long commands
+// may take. It takes a number of seconds or a duration such as 500ms, and 0
+// or off waits indefinitely.
+func timeoutCommand(args []string) {
+	if len(args) > 1 {
+		fmt.Println("Usage: :timeout [seconds|off]")
+		return
+	}
+
+	if len(args) == 1 {
+		timeout, err := parseTimeout(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		*cmdtimeout = timeout
+	}
+
+	if *cmdtimeout > 0 {
+		fmt.Printf("Commands time out after %v\n", *cmdtimeout)
+	} else {
+		fmt.Println("Commands don't time out")
+	}
+}
+
+// parseTimeout reads a :timeout value
+func parseTimeout(value string) (time.Duration, error) {
+	if value == "off" {
+		return 0, nil
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	if timeout, err := time.ParseDuration(value); err == nil && timeout >= 0 {
+		return timeout, nil
+	}
+	return 0, fmt.Errorf("bad timeout %q, expected seconds, a duration like 500ms or off", value)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCert returns a self-signed PEM encoded certificate
+func testCert(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "redis.test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestPemCert(t *testing.T) {
+	cert := testCert(t)
+	escaped := strings.Replace(cert, "\n", `\n`, -1)
+
+	for _, value := range []string{cert, escaped} {
+		got, err := pemCert(value)
+		if err != nil {
+			t.Errorf("pemCert(%q): %s", value, err)
+			continue
+		}
+		if string(got) != cert {
+			t.Errorf("pemCert(%q) = %q, want %q", value, got, cert)
+		}
+	}
+
+	broken := strings.Replace(cert, "MII", "XXX", 1)
+	for _, value := range []string{"", "not a cert", broken} {
+		if _, err := pemCert(value); err == nil {
+			t.Errorf("pemCert(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestTLSSettings(t *testing.T) {
+	cert := []byte(testCert(t))
+	tests := []struct {
+		name    string
+		rawurl  string
+		cert    []byte
+		wanturl string
+		tls     bool
+		roots   bool
+	}{
+		{"plain", "redis://127.0.0.1:6379/0", nil, "redis://127.0.0.1:6379/0", false, false},
+		{"rediss without a cert", "rediss://127.0.0.1:6379/0", nil, "rediss://127.0.0.1:6379/0", true, false},
+		{"rediss with a cert", "rediss://127.0.0.1:6379/0", cert, "rediss://127.0.0.1:6379/0", true, true},
+		{"a cert upgrades redis", "redis://127.0.0.1:6379/0", cert, "rediss://127.0.0.1:6379/0", true, true},
+	}
+	for _, test := range tests {
+		url, config, err := tlsSettings(test.rawurl, test.cert)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if url != test.wanturl {
+			t.Errorf("%s: url %q, want %q", test.name, url, test.wanturl)
+		}
+		if (config != nil) != test.tls {
+			t.Errorf("%s: TLS config %v, want TLS %v", test.name, config, test.tls)
+			continue
+		}
+		if config != nil && (config.RootCAs != nil) != test.roots {
+			t.Errorf("%s: root CAs %v, want own roots %v", test.name, config.RootCAs, test.roots)
+		}
+	}
+
+	if _, _, err := tlsSettings("rediss://127.0.0.1:6379/0", []byte("not a cert")); err == nil {
+		t.Error("tlsSettings with a bad cert succeeded, want an error")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// transcript is the --log-file every command and reply is appended to
+var transcript *os.File
+
+// openTranscript opens the --log-file for appending, readable only by the
+// user as it may hold sensitive data
+func openTranscript(path string) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		log.Fatal(err)
+	}
+	transcript = file
+}
+
+// logCommand appends a timestamped command and its reply to the transcript.
+// Each entry is written straight to the file so none are lost if redli dies.
+func logCommand(parts []string, reply interface{}, err error) {
+	if transcript == nil {
+		return
+	}
+
+	var out string
+	if err != nil && reply == nil {
+		out = fmt.Sprintf("(error) %s\n", err)
+	} else {
+		out, _ = humanFormatter{}.Format(reply)
+	}
+
+	entry := fmt.Sprintf("%s > %s\n%s", time.Now().Format(time.RFC3339), quoteCommand(redactCommand(parts)), out)
+	if _, err := transcript.WriteString(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Can't write to log file: %s\n", err)
+	}
+}
+
+// redactCommand returns a copy of a command with passwords hidden, which
+// are the arguments of AUTH and anything after an AUTH or AUTH2 option as
+// in HELLO and MIGRATE
+func redactCommand(parts []string) []string {
+	redacted := make([]string, len(parts))
+	copy(redacted, parts)
+
+	hiding := strings.ToUpper(parts[0]) == "AUTH"
+	for i := 1; i < len(redacted); i++ {
+		if hiding {
+			redacted[i] = "(redacted)"
+			continue
+		}
+		switch strings.ToUpper(redacted[i]) {
+		case "AUTH", "AUTH2":
+			hiding = true
+		}
+	}
+	return redacted
+}
+
+// hasPassword reports whether redactCommand would hide any of a command
+func hasPassword(parts []string) bool {
+	for i, part := range redactCommand(parts) {
+		if part != parts[i] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// typeProgressEvery is how many keys are counted between progress reports
+const typeProgressEvery = 1000
+
+// countByType counts the keys matching pattern of each type and prints them
+// as a table with each type's share. TYPE is pipelined on a connection of
+// its own, so it doesn't disturb the SCAN.
+func countByType(pattern string) {
+	typeconn, err := dial()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer typeconn.Close()
+
+	counts := map[string]int{}
+	total := 0
+	window := newPipelineWindow(typeconn, func(reply interface{}, err error) error {
+		keytype, err := redis.String(reply, err)
+		if err != nil {
+			return err
+		}
+		// Keys deleted since the SCAN have type none
+		if keytype != "none" {
+			counts[keytype]++
+			total++
+			if total%typeProgressEvery == 0 {
+				fmt.Fprintf(os.Stderr, "\rScanned %d keys", total)
+			}
+		}
+		return nil
+	})
+
+	err = scanKeys(pattern, *scancursor, *scanlimit, func(key string) error {
+		return window.send("TYPE", key)
+	})
+	if err == nil {
+		err = window.drain()
+	}
+	fmt.Fprintf(os.Stderr, "\rScanned %d keys\n", total)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	types := make([]string, 0, len(counts))
+	for keytype := range counts {
+		types = append(types, keytype)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if counts[types[i]] != counts[types[j]] {
+			return counts[types[i]] > counts[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	rows := [][]string{}
+	for _, keytype := range types {
+		rows = append(rows, []string{keytype, strconv.Itoa(counts[keytype]), fmt.Sprintf("%.1f%%", percent(counts[keytype], total))})
+	}
+	rows = append(rows, []string{"total", strconv.Itoa(total), ""})
+	fmt.Print(formatTable([]string{"type", "keys", "share"}, rows))
+}
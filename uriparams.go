@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uriParams applies the query parameters redli understands from the URIs
+// managed services hand out: db picks the database when the path doesn't,
+// tls=true switches to rediss: and timeout is returned for the caller to
+// use. The query is then dropped, ignoring any other parameters, with a note
+// of each when --debug is on.
+func uriParams(u *url.URL) (time.Duration, error) {
+	query := u.Query()
+	u.RawQuery = ""
+
+	if values, ok := query["db"]; ok {
+		db, err := strconv.Atoi(values[0])
+		if err != nil || db < 0 {
+			return 0, fmt.Errorf("bad database %q in URI", values[0])
+		}
+		if path := strings.TrimPrefix(u.Path, "/"); path != "" && path != values[0] {
+			return 0, fmt.Errorf("URI gives database %s in its path but db=%s", path, values[0])
+		}
+		u.Path = "/" + values[0]
+		delete(query, "db")
+	}
+
+	if values, ok := query["tls"]; ok {
+		usetls, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return 0, fmt.Errorf("bad tls=%s in URI, expected true or false", values[0])
+		}
+		if usetls {
+			u.Scheme = "rediss"
+		} else if u.Scheme == "rediss" {
+			return 0, fmt.Errorf("URI has tls=false but a rediss: scheme")
+		}
+		delete(query, "tls")
+	}
+
+	var timeout time.Duration
+	if values, ok := query["timeout"]; ok {
+		var err error
+		timeout, err = parseTimeout(values[0])
+		if err != nil {
+			return 0, fmt.Errorf("bad timeout=%s in URI, expected seconds or a duration like 500ms", values[0])
+		}
+		delete(query, "timeout")
+	}
+
+	if *debug {
+		names := make([]string, 0, len(query))
+		for name := range query {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(os.Stderr, "Ignoring URI parameter %s\n", name)
+		}
+	}
+	return timeout, nil
+}
+
+// uriDatabase returns the database a URI selects in its path. A database in
+// the URI wins over --ndb, which is given as ndb and written into the path of
+// a URI that has none.
+func uriDatabase(u *url.URL, ndb int) (int, error) {
+	path := strings.TrimPrefix(u.Path, "/")
+	if path == "" {
+		u.Path = "/" + strconv.Itoa(ndb)
+		return ndb, nil
+	}
+	db, err := strconv.Atoi(path)
+	if err != nil || db < 0 {
+		return 0, fmt.Errorf("bad database %q in URI", path)
+	}
+	return db, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// defaultWatchInterval is how often :watch reruns its command by default
+const defaultWatchInterval = 2 * time.Second
+
+// watchCommand implements :watch <command> [interval], rerunning a command
+// and redrawing its reply until Ctrl-C is pressed. Aliases, @file arguments
+// and the production guard apply as they do at the prompt, with the guard
+// asked once rather than on every run.
+func watchCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: :watch <command> [interval]")
+		return
+	}
+
+	interval := defaultWatchInterval
+	if len(args) > 1 {
+		if seconds, err := strconv.ParseFloat(args[len(args)-1], 64); err == nil && seconds > 0 {
+			interval = time.Duration(seconds * float64(time.Second))
+			args = args[:len(args)-1]
+		}
+	}
+
+	args, err := expandAlias(args)
+	if err == nil {
+		args, err = expandHelper(args)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Show the command once rather than redrawing it
+	if dryRunCommand(args) {
+		return
+	}
+	if !confirmProduction(args) {
+		return
+	}
+
+	sendargs, err := fileArgs(args[1:])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fmt.Print("\x1b[H\x1b[2J")
+		fmt.Printf("Every %v: %s    %s\n\n", interval, strings.Join(args, " "), time.Now().Format("15:04:05"))
+
+		var reply interface{}
+		if isBlockingCommand(args) {
+			reply, err = doInterruptible(args[0], sendargs...)
+			if err == errInterrupted {
+				fmt.Println("Interrupted")
+				return
+			}
+		} else {
+			reply, err = doWithTimeout(args[0], sendargs...)
+		}
+		if err != nil {
+			if _, ok := err.(redis.Error); !ok {
+				fmt.Println(err)
+				return
+			}
+			reply = err
+		}
+		printReply(args, reply)
+
+		select {
+		case <-ticker.C:
+		case <-interrupt:
+			fmt.Println()
+			return
+		}
+	}
+}
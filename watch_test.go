@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWatchConfirmsAgainstProduction(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	setFlag(t, &os.Stdin, r)
+	setFlag(t, &environment, "prod")
+	recording := useConn(t, map[string]interface{}{})
+
+	captureStdout(t, func() { watchCommand([]string{"FLUSHDB", "1"}) })
+	if len(recording.commands) != 0 {
+		t.Errorf(":watch FLUSHDB against prod sent %q without confirming", recording.commands)
+	}
+}
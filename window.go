@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/gomodule/redigo/redis"
+)
+
+// pipelineWindow pipelines commands on a connection with at most max of
+// them sent and waiting for their replies. When the window fills, the older
+// half of the replies are read, so the server works through the rest while
+// more are sent.
+type pipelineWindow struct {
+	conn    redis.Conn
+	max     int
+	pending int
+	reply   func(reply interface{}, err error) error
+}
+
+// newPipelineWindow returns a window of --max-inflight commands on conn,
+// which passes each reply, in order, to the reply function
+func newPipelineWindow(conn redis.Conn, reply func(reply interface{}, err error) error) *pipelineWindow {
+	return &pipelineWindow{conn: conn, max: *maxinflight, reply: reply}
+}
+
+// send queues a command, first reading replies if the window is full
+func (w *pipelineWindow) send(command string, args ...interface{}) error {
+	if err := w.conn.Send(command, args...); err != nil {
+		return err
+	}
+	w.pending++
+	if w.pending < w.max {
+		return nil
+	}
+	return w.receive(w.pending - w.max/2)
+}
+
+// drain reads the replies still to come
+func (w *pipelineWindow) drain() error {
+	return w.receive(w.pending)
+}
+
+// receive sends any commands still buffered, then reads n replies
+func (w *pipelineWindow) receive(n int) error {
+	if err := w.conn.Flush(); err != nil {
+		return err
+	}
+	for ; n > 0; n-- {
+		reply, err := w.conn.Receive()
+		w.pending--
+		if err := w.reply(reply, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}